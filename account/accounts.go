@@ -0,0 +1,150 @@
+package account
+
+import (
+	"sync"
+
+	"github.com/shopspring/decimal"
+)
+
+// Balance is what an owner holds of one asset. Locked funds back resting
+// orders and can not be spent elsewhere until they are released or settled.
+type Balance struct {
+	Available decimal.Decimal `json:"available"`
+	Locked    decimal.Decimal `json:"locked"`
+}
+
+func (b Balance) Total() decimal.Decimal {
+	return b.Available.Add(b.Locked)
+}
+
+// Accounts keeps the balances of every owner by asset. It is safe for
+// concurrent use, so one Accounts can back all engines of an Exchange.
+type Accounts struct {
+	balances map[string]map[string]*Balance
+	mu       sync.Mutex
+}
+
+func NewAccounts() *Accounts {
+	return &Accounts{
+		balances: make(map[string]map[string]*Balance),
+	}
+}
+
+func (a *Accounts) Deposit(ownerID, asset string, amount decimal.Decimal) error {
+	if !amount.IsPositive() {
+		return ErrInvalidAmount
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	b := a.balance(ownerID, asset)
+	b.Available = b.Available.Add(amount)
+	return nil
+}
+
+func (a *Accounts) Withdraw(ownerID, asset string, amount decimal.Decimal) error {
+	if !amount.IsPositive() {
+		return ErrInvalidAmount
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	b := a.balance(ownerID, asset)
+	if b.Available.LessThan(amount) {
+		return ErrInsufficientFunds
+	}
+	b.Available = b.Available.Sub(amount)
+	return nil
+}
+
+func (a *Accounts) GetBalance(ownerID, asset string) Balance {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if b := a.balances[ownerID][asset]; b != nil {
+		return *b
+	}
+	return Balance{}
+}
+
+// GetBalances returns every balance of an owner by asset.
+func (a *Accounts) GetBalances(ownerID string) map[string]Balance {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	balances := make(map[string]Balance, len(a.balances[ownerID]))
+	for asset, b := range a.balances[ownerID] {
+		balances[asset] = *b
+	}
+	return balances
+}
+
+// Lock moves amount from available to locked, or fails with
+// ErrInsufficientFunds without changing anything.
+func (a *Accounts) Lock(ownerID, asset string, amount decimal.Decimal) error {
+	if amount.IsNegative() {
+		return ErrInvalidAmount
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	b := a.balance(ownerID, asset)
+	if b.Available.LessThan(amount) {
+		return ErrInsufficientFunds
+	}
+	b.Available = b.Available.Sub(amount)
+	b.Locked = b.Locked.Add(amount)
+	return nil
+}
+
+// Unlock moves amount from locked back to available. No more than what is
+// locked is moved.
+func (a *Accounts) Unlock(ownerID, asset string, amount decimal.Decimal) {
+	if !amount.IsPositive() {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	b := a.balance(ownerID, asset)
+	amount = decimal.Min(amount, b.Locked)
+	b.Locked = b.Locked.Sub(amount)
+	b.Available = b.Available.Add(amount)
+}
+
+// Settlement is one trade to settle: the buyer pays QuoteAmount of the Quote
+// asset for Units of the Base asset. Each side receives its part less its
+// fee, which is in the asset it receives and adds to what it gets when
+// negative.
+type Settlement struct {
+	BuyerID     string
+	SellerID    string
+	Base        string
+	Quote       string
+	Units       decimal.Decimal
+	QuoteAmount decimal.Decimal
+	BuyerFee    decimal.Decimal
+	SellerFee   decimal.Decimal
+}
+
+// Settle exchanges the locked funds of a trade.
+func (a *Accounts) Settle(st Settlement) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	buyerQuote := a.balance(st.BuyerID, st.Quote)
+	buyerQuote.Locked = buyerQuote.Locked.Sub(st.QuoteAmount)
+	sellerBase := a.balance(st.SellerID, st.Base)
+	sellerBase.Locked = sellerBase.Locked.Sub(st.Units)
+	buyerBase := a.balance(st.BuyerID, st.Base)
+	buyerBase.Available = buyerBase.Available.Add(st.Units.Sub(st.BuyerFee))
+	sellerQuote := a.balance(st.SellerID, st.Quote)
+	sellerQuote.Available = sellerQuote.Available.Add(st.QuoteAmount.Sub(st.SellerFee))
+}
+
+func (a *Accounts) balance(ownerID, asset string) *Balance {
+	assets := a.balances[ownerID]
+	if assets == nil {
+		assets = make(map[string]*Balance)
+		a.balances[ownerID] = assets
+	}
+	b := assets[asset]
+	if b == nil {
+		b = &Balance{}
+		assets[asset] = b
+	}
+	return b
+}
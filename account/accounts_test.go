@@ -0,0 +1,71 @@
+package account_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dylantkx/matching-engine-core/account"
+	"github.com/shopspring/decimal"
+)
+
+func TestLockAndUnlock(t *testing.T) {
+	acc := account.NewAccounts()
+	acc.Deposit("alice", "USD", decimal.NewFromInt(100))
+
+	if err := acc.Lock("alice", "USD", decimal.NewFromInt(150)); !errors.Is(err, account.ErrInsufficientFunds) {
+		t.Fatalf("expect ErrInsufficientFunds but got %v", err)
+	}
+	if err := acc.Lock("alice", "USD", decimal.NewFromInt(60)); err != nil {
+		t.Fatalf("expect lock to succeed but got %v", err)
+	}
+	b := acc.GetBalance("alice", "USD")
+	if !b.Available.Equal(decimal.NewFromInt(40)) || !b.Locked.Equal(decimal.NewFromInt(60)) {
+		t.Fatalf("expect 40 available and 60 locked but got %+v", b)
+	}
+	if err := acc.Withdraw("alice", "USD", decimal.NewFromInt(50)); !errors.Is(err, account.ErrInsufficientFunds) {
+		t.Fatalf("expect locked funds not to be withdrawn but got %v", err)
+	}
+
+	acc.Unlock("alice", "USD", decimal.NewFromInt(100))
+	b = acc.GetBalance("alice", "USD")
+	if !b.Available.Equal(decimal.NewFromInt(100)) || !b.Locked.IsZero() {
+		t.Fatalf("expect 100 available and nothing locked but got %+v", b)
+	}
+}
+
+func TestSettle(t *testing.T) {
+	acc := account.NewAccounts()
+	acc.Deposit("alice", "USD", decimal.NewFromInt(300))
+	acc.Deposit("bob", "BTC", decimal.NewFromInt(2))
+	acc.Lock("alice", "USD", decimal.NewFromInt(300))
+	acc.Lock("bob", "BTC", decimal.NewFromInt(2))
+
+	acc.Settle(account.Settlement{
+		BuyerID:     "alice",
+		SellerID:    "bob",
+		Base:        "BTC",
+		Quote:       "USD",
+		Units:       decimal.NewFromInt(2),
+		QuoteAmount: decimal.NewFromInt(200),
+		SellerFee:   decimal.NewFromInt(1),
+	})
+
+	expected := map[string]map[string]account.Balance{
+		"alice": {
+			"USD": {Available: decimal.Zero, Locked: decimal.NewFromInt(100)},
+			"BTC": {Available: decimal.NewFromInt(2), Locked: decimal.Zero},
+		},
+		"bob": {
+			"USD": {Available: decimal.NewFromInt(199), Locked: decimal.Zero},
+			"BTC": {Available: decimal.Zero, Locked: decimal.Zero},
+		},
+	}
+	for owner, assets := range expected {
+		for asset, want := range assets {
+			got := acc.GetBalance(owner, asset)
+			if !got.Available.Equal(want.Available) || !got.Locked.Equal(want.Locked) {
+				t.Fatalf("expect %s %s to be %+v but got %+v", owner, asset, want, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,8 @@
+package account
+
+import "errors"
+
+var (
+	ErrInvalidAmount     = errors.New("amount must be positive")
+	ErrInsufficientFunds = errors.New("insufficient funds")
+)
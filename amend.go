@@ -0,0 +1,89 @@
+package matchingenginecore
+
+import (
+	"time"
+
+	"github.com/dylantkx/matching-engine-core/model"
+	"github.com/dylantkx/matching-engine-core/orderbook"
+)
+
+// AmendOrder changes the price and/or remaining units of a resting limit
+// order. Decreasing the units at the same price keeps the order's place in the
+// queue. Any other change requeues the order at the back of its new level,
+// where it is matched first if the new price crosses the book; those trades
+// are reported in the returned result. If the requeued order is rejected, for
+// example a post-only order that would now cross, it is not restored.
+func (me *MatchingEngine) AmendOrder(amend *model.OrderAmend) (model.MatchResult, model.OrderAmendAck, error) {
+	cr := me.execute(Command{Amend: amend}, time.Now())
+	if cr.AmendAck == nil {
+		return cr.Result, model.OrderAmendAck{}, cr.Err
+	}
+	return cr.Result, *cr.AmendAck, cr.Err
+}
+
+func (me *MatchingEngine) amendOrder(amend *model.OrderAmend) (r model.MatchResult, ack model.OrderAmendAck, err error) {
+	order, ok := me.orders[amend.OrderID]
+	resting, onBook := me.book.GetOrder(amend.OrderID)
+	if !ok || !onBook {
+		err = &orderbook.OrderNotFoundError{OrderID: amend.OrderID}
+		return
+	}
+	if amend.Units.IsNegative() || amend.Price.IsNegative() {
+		err = ErrInvalidAmend
+		return
+	}
+	if !me.scales.CanRepresentUnits(amend.Units) || !me.scales.CanRepresentPrice(amend.Price) {
+		err = ErrPrecisionExceeded
+		return
+	}
+
+	units := resting.GetTotalUnits()
+	if amend.Units.IsPositive() {
+		units = amend.Units
+	}
+	price := order.Price
+	if amend.Price.IsPositive() {
+		price = amend.Price
+	}
+	if reason := me.rules.CheckOrder(&model.Order{Units: units, Price: price}); reason != "" {
+		err = &OrderRejectedError{OrderID: order.ID, Reason: reason}
+		return
+	}
+	ack = model.OrderAmendAck{
+		OrderID:      order.ID,
+		Units:        units,
+		Price:        price,
+		KeptPriority: true,
+	}
+
+	s, _ := me.trackOrder(order.ID, order.OwnerID, order.Side, order.Price, resting.GetTotalUnits())
+	if price.Equal(order.Price) && units.LessThanOrEqual(resting.GetTotalUnits()) {
+		if units.LessThan(resting.GetTotalUnits()) {
+			if err = me.book.DecreaseOrder(order.ID, units); err != nil {
+				return
+			}
+		}
+		s.LeavesUnits = units
+		me.releaseFunds(s, lockedFor(order.Side, units, price))
+		me.report(&r, s, model.ExecType_Replaced, s.liveStatus(), "")
+		return
+	}
+
+	if !me.holdFunds(s, lockedFor(order.Side, units, price)) {
+		err = &OrderRejectedError{OrderID: order.ID, Reason: model.RejectReason_InsufficientFunds}
+		return
+	}
+	me.book.CancelOrderByID(order.ID)
+	// restLimitOrder adds them back if the requeued order rests
+	delete(me.orders, order.ID)
+	me.expiries.Remove(order.ID)
+	s.LeavesUnits = units
+	s.Price = price
+	me.report(&r, s, model.ExecType_Replaced, s.liveStatus(), "")
+	order.Units = units
+	order.Price = price
+	ack.KeptPriority = false
+	r.Append(me.processLimitOrder(&order))
+	me.releaseStops(&r, r.Trades)
+	return
+}
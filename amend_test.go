@@ -0,0 +1,162 @@
+package matchingenginecore_test
+
+import (
+	"errors"
+	"testing"
+
+	me "github.com/dylantkx/matching-engine-core"
+	"github.com/dylantkx/matching-engine-core/model"
+	"github.com/dylantkx/matching-engine-core/orderbook"
+	"github.com/shopspring/decimal"
+)
+
+func newAmendTestEngine() *me.MatchingEngine {
+	engine := me.NewMatchingEngine()
+	sellOrders := []model.OrderLimit{
+		{
+			ID:    "1",
+			Units: decimal.NewFromFloat(2),
+			Price: decimal.NewFromFloat(100),
+			Side:  model.OrderSide_Sell,
+		},
+		{
+			ID:    "2",
+			Units: decimal.NewFromFloat(2),
+			Price: decimal.NewFromFloat(100),
+			Side:  model.OrderSide_Sell,
+		},
+	}
+	for _, ord := range sellOrders {
+		engine.ProcessLimitOrder(&ord)
+	}
+	return engine
+}
+
+func TestAmendOrderDecreaseKeepsPriority(t *testing.T) {
+	engine := newAmendTestEngine()
+
+	_, ack, err := engine.AmendOrder(&model.OrderAmend{
+		OrderID: "1",
+		Units:   decimal.NewFromFloat(1),
+	})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !ack.KeptPriority || !ack.Units.Equal(decimal.NewFromFloat(1)) {
+		t.Fatalf("wrong ack output: %+v", ack)
+	}
+
+	r := engine.ProcessMarketOrder(&model.OrderMarket{
+		ID:    "3",
+		Units: decimal.NewFromFloat(1),
+		Side:  model.OrderSide_Buy,
+	})
+	if len(r.Trades) != 1 || r.Trades[0].SellOrderID != "1" {
+		t.Fatalf("expect order 1 to keep priority, but got %+v", r.Trades)
+	}
+}
+
+func TestAmendOrderNegativePriceRejected(t *testing.T) {
+	engine := newAmendTestEngine()
+
+	_, _, err := engine.AmendOrder(&model.OrderAmend{
+		OrderID: "1",
+		Price:   decimal.NewFromFloat(-1),
+	})
+	if err != me.ErrInvalidAmend {
+		t.Fatalf("expect ErrInvalidAmend but got %v", err)
+	}
+	if units := engine.GetTotalSellUnitsToPrice(decimal.NewFromFloat(100)); !units.Equal(decimal.NewFromFloat(4)) {
+		t.Fatalf("expect book to be untouched, but got %s units", units)
+	}
+}
+
+func TestAmendOrderIncreaseLosesPriority(t *testing.T) {
+	engine := newAmendTestEngine()
+
+	_, ack, err := engine.AmendOrder(&model.OrderAmend{
+		OrderID: "1",
+		Units:   decimal.NewFromFloat(3),
+	})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if ack.KeptPriority {
+		t.Fatalf("wrong ack output: %+v", ack)
+	}
+
+	r := engine.ProcessMarketOrder(&model.OrderMarket{
+		ID:    "3",
+		Units: decimal.NewFromFloat(1),
+		Side:  model.OrderSide_Buy,
+	})
+	if len(r.Trades) != 1 || r.Trades[0].SellOrderID != "2" {
+		t.Fatalf("expect order 2 to be first in queue, but got %+v", r.Trades)
+	}
+	if units := engine.GetTotalSellUnitsToPrice(decimal.NewFromFloat(100)); !units.Equal(decimal.NewFromFloat(4)) {
+		t.Fatalf("expect to units = 4, but got %s", units)
+	}
+}
+
+func TestAmendOrderPriceCrosses(t *testing.T) {
+	engine := newAmendTestEngine()
+	engine.ProcessLimitOrder(&model.OrderLimit{
+		ID:    "3",
+		Units: decimal.NewFromFloat(1),
+		Price: decimal.NewFromFloat(90),
+		Side:  model.OrderSide_Buy,
+	})
+
+	r, ack, err := engine.AmendOrder(&model.OrderAmend{
+		OrderID: "3",
+		Price:   decimal.NewFromFloat(100),
+	})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if ack.KeptPriority || !ack.Price.Equal(decimal.NewFromFloat(100)) {
+		t.Fatalf("wrong ack output: %+v", ack)
+	}
+	if len(r.Trades) != 1 || r.Trades[0].BuyOrderID != "3" || r.Trades[0].SellOrderID != "1" {
+		t.Fatalf("wrong trade output: %+v", r.Trades)
+	}
+	if p := engine.GetHighestBuyPrice(); !p.IsZero() {
+		t.Fatalf("expect no stale buy order, but highest buy is %s", p)
+	}
+	if sn := engine.Snapshot(); len(sn.Limits) != 2 {
+		t.Fatalf("expect only the 2 resting sells in the snapshot, but got %+v", sn.Limits)
+	}
+
+	_, _, err = engine.AmendOrder(&model.OrderAmend{
+		OrderID: "3",
+		Units:   decimal.NewFromFloat(1),
+	})
+	if !errors.Is(err, orderbook.ErrOrderNotFound) {
+		t.Fatalf("expect order not found error, but got %v", err)
+	}
+}
+
+func TestResubmittedOrderIDRejected(t *testing.T) {
+	engine := newAmendTestEngine()
+
+	for _, price := range []float64{100, 101} {
+		r := engine.ProcessLimitOrder(&model.OrderLimit{
+			ID:    "1",
+			Units: decimal.NewFromFloat(5),
+			Price: decimal.NewFromFloat(price),
+			Side:  model.OrderSide_Sell,
+		})
+		if len(r.Rejections) != 1 || r.Rejections[0].Reason != model.RejectReason_DuplicateOrderID {
+			t.Fatalf("expect order at %v to be rejected as a duplicate but got %+v", price, r)
+		}
+	}
+
+	r := engine.ProcessMarketOrder(&model.OrderMarket{
+		ID:    "3",
+		Units: decimal.NewFromFloat(5),
+		Side:  model.OrderSide_Buy,
+	})
+	if len(r.Trades) != 2 || r.Trades[0].SellOrderID != "1" || !r.Trades[0].Units.Equal(decimal.NewFromFloat(2)) {
+		t.Fatalf("expect the resting orders to be left as they were but got %+v", r.Trades)
+	}
+}
@@ -0,0 +1,180 @@
+package matchingenginecore
+
+import (
+	"sort"
+	"time"
+
+	"github.com/dylantkx/matching-engine-core/model"
+	"github.com/dylantkx/matching-engine-core/orderbook"
+	"github.com/shopspring/decimal"
+)
+
+// StartAuction stops continuous matching. Limit orders are collected on the
+// book without trading until Uncross is called, and the indicative uncross is
+// reported with every change. Market orders and immediate-or-cancel or
+// fill-or-kill limit orders are rejected meanwhile, and stop orders are not
+// released.
+func (me *MatchingEngine) StartAuction() model.MatchResult {
+	return me.execute(Command{StartAuction: true}, time.Now()).Result
+}
+
+// Uncross ends the auction. Every order that can trade at the indicative
+// price is executed at that single price, and the engine moves on to
+// continuous matching. In the pre-open session it opens the session.
+// Self-trade prevention is not applied: both sides are cleared against each
+// other at once, so an owner's buy and sell orders may trade together.
+func (me *MatchingEngine) Uncross() model.MatchResult {
+	return me.execute(Command{Uncross: true}, time.Now()).Result
+}
+
+func (me *MatchingEngine) IsInAuction() bool {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	return me.auction
+}
+
+// GetIndicative returns the indicative uncross of the running auction.
+func (me *MatchingEngine) GetIndicative() model.AuctionIndicative {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	return me.indicative
+}
+
+// updateIndicative recomputes the indicative uncross and reports it in r if
+// it changed.
+func (me *MatchingEngine) updateIndicative(r *model.MatchResult) {
+	ind := me.indicativeUncross()
+	if ind.Equal(&me.indicative) {
+		return
+	}
+	me.indicative = ind
+	r.Indicative = &ind
+	me.publish(model.Event{Type: model.EventType_Indicative, Indicative: &ind})
+}
+
+// indicativeUncross finds the price at which the most units would trade. Ties
+// go to the price leaving the smallest imbalance, then to the price closest
+// to the last trade price. Before any trade, the middle of the tied prices is
+// used as the reference instead.
+func (me *MatchingEngine) indicativeUncross() model.AuctionIndicative {
+	sn := me.book.GetTotalSnapshot()
+	buys, sells := sn.Buys, sn.Sells
+	if len(buys) == 0 || len(sells) == 0 || buys[0].Price.LessThan(sells[0].Price) {
+		return model.AuctionIndicative{}
+	}
+
+	// only prices between the best sell and the best buy can execute anything
+	prices := make([]decimal.Decimal, 0)
+	for _, rec := range buys {
+		if rec.Price.GreaterThanOrEqual(sells[0].Price) {
+			prices = append(prices, rec.Price)
+		}
+	}
+	for _, rec := range sells {
+		if rec.Price.LessThanOrEqual(buys[0].Price) {
+			prices = append(prices, rec.Price)
+		}
+	}
+	sort.Slice(prices, func(i, j int) bool {
+		return prices[i].LessThan(prices[j])
+	})
+
+	type candidate struct {
+		price, buyUnits, sellUnits decimal.Decimal
+	}
+	candidates := make([]candidate, len(prices))
+	cum, k := decimal.Zero, 0
+	for i := len(prices) - 1; i >= 0; i-- {
+		for ; k < len(buys) && buys[k].Price.GreaterThanOrEqual(prices[i]); k++ {
+			cum = cum.Add(buys[k].Size)
+		}
+		candidates[i].price = prices[i]
+		candidates[i].buyUnits = cum
+	}
+	cum, k = decimal.Zero, 0
+	for i := range prices {
+		for ; k < len(sells) && sells[k].Price.LessThanOrEqual(prices[i]); k++ {
+			cum = cum.Add(sells[k].Size)
+		}
+		candidates[i].sellUnits = cum
+	}
+
+	var best []candidate
+	var bestUnits, bestImbalance decimal.Decimal
+	for _, c := range candidates {
+		units := decimal.Min(c.buyUnits, c.sellUnits)
+		imbalance := c.buyUnits.Sub(c.sellUnits).Abs()
+		switch {
+		case len(best) == 0 || units.GreaterThan(bestUnits) ||
+			(units.Equal(bestUnits) && imbalance.LessThan(bestImbalance)):
+			best = append(best[:0], c)
+			bestUnits, bestImbalance = units, imbalance
+		case units.Equal(bestUnits) && imbalance.Equal(bestImbalance):
+			best = append(best, c)
+		}
+	}
+
+	ref := me.lastPrice
+	if !ref.IsPositive() {
+		ref = best[0].price.Add(best[len(best)-1].price).Div(decimal.NewFromInt(2))
+	}
+	chosen := best[0]
+	for _, c := range best[1:] {
+		if c.price.Sub(ref).Abs().LessThan(chosen.price.Sub(ref).Abs()) {
+			chosen = c
+		}
+	}
+
+	ind := model.AuctionIndicative{
+		Price:          chosen.price,
+		Units:          bestUnits,
+		ImbalanceUnits: bestImbalance,
+	}
+	switch {
+	case chosen.buyUnits.GreaterThan(chosen.sellUnits):
+		ind.ImbalanceSide = model.OrderSide_Buy
+	case chosen.sellUnits.GreaterThan(chosen.buyUnits):
+		ind.ImbalanceSide = model.OrderSide_Sell
+	}
+	return ind
+}
+
+// uncross executes the indicative uncross at its single price and ends the
+// auction. Buy and sell fills are paired in price-time priority. There is no
+// incoming order to cancel, so self-trade prevention is left out: skipping an
+// owner's orders would change the volume the indicative price was found for.
+func (me *MatchingEngine) uncross() (r model.MatchResult) {
+	ind := me.indicativeUncross()
+	me.auction = false
+	me.indicative = model.AuctionIndicative{}
+	if !ind.Units.IsPositive() {
+		return
+	}
+
+	buys := me.book.ClearBuySide(ind.Units, orderbook.ClearOptions{Price: &ind.Price})
+	sells := me.book.ClearSellSide(ind.Units, orderbook.ClearOptions{Price: &ind.Price})
+	var bought, sold decimal.Decimal
+	for i, j := 0, 0; i < len(buys.ClearedOrders) && j < len(sells.ClearedOrders); {
+		buy, sell := buys.ClearedOrders[i], sells.ClearedOrders[j]
+		units := decimal.Min(buy.Units.Sub(bought), sell.Units.Sub(sold))
+		me.addTrade(&r, model.Trade{
+			Symbol:      me.symbol,
+			BuyOrderID:  buy.ID,
+			SellOrderID: sell.ID,
+			Units:       units,
+			Price:       ind.Price,
+			EventTime:   model.Timestamp{Time: me.now},
+		})
+		if bought = bought.Add(units); bought.Equal(buy.Units) {
+			bought = decimal.Zero
+			i++
+		}
+		if sold = sold.Add(units); sold.Equal(sell.Units) {
+			sold = decimal.Zero
+			j++
+		}
+	}
+	me.forgetInactiveOrders(buys)
+	me.forgetInactiveOrders(sells)
+	return
+}
@@ -0,0 +1,127 @@
+package matchingenginecore_test
+
+import (
+	"testing"
+
+	me "github.com/dylantkx/matching-engine-core"
+	"github.com/dylantkx/matching-engine-core/model"
+	"github.com/shopspring/decimal"
+)
+
+func TestAuctionUncross(t *testing.T) {
+	engine := me.NewMatchingEngine(me.WithOpeningAuction())
+
+	addLimit(engine, "b1", model.OrderSide_Buy, 10, 101)
+	addLimit(engine, "b2", model.OrderSide_Buy, 5, 100)
+	addLimit(engine, "b3", model.OrderSide_Buy, 5, 99)
+	r := addLimit(engine, "s1", model.OrderSide_Sell, 8, 98)
+	if len(r.Trades) != 0 || r.Indicative == nil || !r.Indicative.Price.Equal(decimal.NewFromFloat(101)) || !r.Indicative.Units.Equal(decimal.NewFromFloat(8)) {
+		t.Fatalf("expect indicative 8 @ 101 without trades but got %+v", r)
+	}
+	addLimit(engine, "s2", model.OrderSide_Sell, 6, 100)
+	r = addLimit(engine, "s3", model.OrderSide_Sell, 10, 102)
+	if r.Indicative != nil {
+		t.Fatalf("expect no indicative change but got %+v", r.Indicative)
+	}
+
+	ind := engine.GetIndicative()
+	if !ind.Price.Equal(decimal.NewFromFloat(100)) || !ind.Units.Equal(decimal.NewFromFloat(14)) ||
+		!ind.ImbalanceUnits.Equal(decimal.NewFromFloat(1)) || ind.ImbalanceSide != model.OrderSide_Buy {
+		t.Fatalf("wrong indicative: %+v", ind)
+	}
+
+	r = engine.Uncross()
+	expected := []struct {
+		buy, sell string
+		units     float64
+	}{{"b1", "s1", 8}, {"b1", "s2", 2}, {"b2", "s2", 4}}
+	if len(r.Trades) != len(expected) {
+		t.Fatalf("expect %d trades but got %+v", len(expected), r.Trades)
+	}
+	for i, e := range expected {
+		tr := r.Trades[i]
+		if tr.BuyOrderID != e.buy || tr.SellOrderID != e.sell || !tr.Units.Equal(decimal.NewFromFloat(e.units)) || !tr.Price.Equal(decimal.NewFromFloat(100)) {
+			t.Fatalf("wrong trade %d: %+v", i, tr)
+		}
+	}
+	if engine.IsInAuction() {
+		t.Fatalf("expect engine to trade continuously after the uncross")
+	}
+	if p := engine.GetHighestBuyPrice(); !p.Equal(decimal.NewFromFloat(100)) {
+		t.Fatalf("expect highest buy to be 100, but got %s", p)
+	}
+	if p := engine.GetLowestSellPrice(); !p.Equal(decimal.NewFromFloat(102)) {
+		t.Fatalf("expect lowest sell to be 102, but got %s", p)
+	}
+
+	r = addLimit(engine, "s4", model.OrderSide_Sell, 1, 100)
+	if len(r.Trades) != 1 || r.Trades[0].BuyOrderID != "b2" {
+		t.Fatalf("expect continuous matching but got %+v", r.Trades)
+	}
+}
+
+func TestAuctionTieBreakers(t *testing.T) {
+	engine := me.NewMatchingEngine(me.WithOpeningAuction())
+	addLimit(engine, "b1", model.OrderSide_Buy, 6, 101)
+	addLimit(engine, "b2", model.OrderSide_Buy, 4, 100)
+	addLimit(engine, "s1", model.OrderSide_Sell, 6, 100)
+	if ind := engine.GetIndicative(); !ind.Price.Equal(decimal.NewFromFloat(101)) || !ind.ImbalanceUnits.IsZero() {
+		t.Fatalf("expect the price with the smallest imbalance but got %+v", ind)
+	}
+
+	engine = me.NewMatchingEngine()
+	addLimit(engine, "1", model.OrderSide_Sell, 1, 105)
+	addLimit(engine, "2", model.OrderSide_Buy, 1, 105)
+	engine.StartAuction()
+	addLimit(engine, "b1", model.OrderSide_Buy, 5, 101)
+	addLimit(engine, "s1", model.OrderSide_Sell, 5, 100)
+	if ind := engine.GetIndicative(); !ind.Price.Equal(decimal.NewFromFloat(101)) {
+		t.Fatalf("expect the price closest to the last trade but got %+v", ind)
+	}
+	r := engine.Uncross()
+	if len(r.Trades) != 1 || !r.Trades[0].Price.Equal(decimal.NewFromFloat(101)) {
+		t.Fatalf("wrong trade output: %+v", r.Trades)
+	}
+}
+
+func TestAuctionUncrossIgnoresSelfTradePrevention(t *testing.T) {
+	engine := me.NewMatchingEngine(me.WithOpeningAuction(), me.WithSelfTradePrevention(model.SelfTradePrevention_CancelNewest))
+	for _, ord := range []model.OrderLimit{
+		{ID: "b1", OwnerID: "alice", Units: decimal.NewFromFloat(2), Price: decimal.NewFromFloat(100), Side: model.OrderSide_Buy},
+		{ID: "s1", OwnerID: "alice", Units: decimal.NewFromFloat(2), Price: decimal.NewFromFloat(100), Side: model.OrderSide_Sell},
+	} {
+		engine.ProcessLimitOrder(&ord)
+	}
+
+	r := engine.Uncross()
+	if len(r.Trades) != 1 || r.Trades[0].BuyOrderID != "b1" || r.Trades[0].SellOrderID != "s1" {
+		t.Fatalf("expect alice's orders to trade in the uncross but got %+v", r.Trades)
+	}
+	if len(r.Cancellations) != 0 {
+		t.Fatalf("expect no cancels but got %+v", r.Cancellations)
+	}
+}
+
+func TestAuctionRejectsImmediateOrders(t *testing.T) {
+	engine := me.NewMatchingEngine(me.WithOpeningAuction())
+	addLimit(engine, "1", model.OrderSide_Sell, 1, 100)
+
+	r := engine.ProcessMarketOrder(&model.OrderMarket{
+		ID:    "2",
+		Units: decimal.NewFromFloat(1),
+		Side:  model.OrderSide_Buy,
+	})
+	if len(r.Rejections) != 1 || r.Rejections[0].Reason != model.RejectReason_AuctionInProgress {
+		t.Fatalf("wrong rejection output: %+v", r.Rejections)
+	}
+	r = engine.ProcessLimitOrder(&model.OrderLimit{
+		ID:          "3",
+		Units:       decimal.NewFromFloat(1),
+		Price:       decimal.NewFromFloat(100),
+		Side:        model.OrderSide_Buy,
+		TimeInForce: model.TimeInForce_IOC,
+	})
+	if len(r.Rejections) != 1 || r.Rejections[0].Reason != model.RejectReason_AuctionInProgress {
+		t.Fatalf("wrong rejection output: %+v", r.Rejections)
+	}
+}
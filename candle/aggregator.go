@@ -0,0 +1,83 @@
+package candle
+
+import (
+	"sync"
+	"time"
+
+	"github.com/dylantkx/matching-engine-core/model"
+)
+
+const DefaultRetention int = 1000
+
+// Aggregator builds candles for a set of intervals from a stream of trades.
+// It can be fed directly with AddTrade or used as an event sink.
+type Aggregator struct {
+	series map[model.CandleInterval]*series
+	mu     sync.RWMutex
+}
+
+// NewAggregator keeps up to retention closed candles for each interval. With
+// no intervals, every supported interval is tracked.
+func NewAggregator(retention int, intervals ...model.CandleInterval) (*Aggregator, error) {
+	if len(intervals) == 0 {
+		intervals = []model.CandleInterval{
+			model.CandleInterval_1s,
+			model.CandleInterval_1m,
+			model.CandleInterval_5m,
+			model.CandleInterval_1h,
+			model.CandleInterval_1d,
+		}
+	}
+	if retention < 1 {
+		retention = DefaultRetention
+	}
+	a := &Aggregator{
+		series: make(map[model.CandleInterval]*series),
+	}
+	for _, interval := range intervals {
+		if _, ok := intervalDurations[interval]; !ok {
+			return nil, ErrUnknownInterval
+		}
+		a.series[interval] = newSeries(interval, retention)
+	}
+	return a, nil
+}
+
+func (a *Aggregator) AddTrade(tr model.Trade) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, s := range a.series {
+		s.addTrade(tr)
+	}
+}
+
+func (a *Aggregator) Publish(event model.Event) {
+	if event.Type == model.EventType_Trade && event.Trade != nil {
+		a.AddTrade(*event.Trade)
+	}
+}
+
+// GetCurrent returns the candle of interval containing now. If nothing traded
+// in it yet, the candle is empty. It returns false until the first trade.
+func (a *Aggregator) GetCurrent(interval model.CandleInterval, now time.Time) (model.Candle, bool, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	s, ok := a.series[interval]
+	if !ok {
+		return model.Candle{}, false, ErrUnknownInterval
+	}
+	c, ok := s.currentAt(now)
+	return c, ok, nil
+}
+
+// GetHistory returns the kept candles of interval opening in [from, to),
+// oldest first, including empty ones and the candle still open.
+func (a *Aggregator) GetHistory(interval model.CandleInterval, from, to time.Time) ([]model.Candle, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	s, ok := a.series[interval]
+	if !ok {
+		return nil, ErrUnknownInterval
+	}
+	return s.history(from, to), nil
+}
@@ -0,0 +1,84 @@
+package candle_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dylantkx/matching-engine-core/candle"
+	"github.com/dylantkx/matching-engine-core/model"
+	"github.com/shopspring/decimal"
+)
+
+func trade(t time.Time, price, units float64) model.Trade {
+	return model.Trade{
+		Units:     decimal.NewFromFloat(units),
+		Price:     decimal.NewFromFloat(price),
+		EventTime: model.Timestamp{Time: t},
+	}
+}
+
+func TestAggregatorFillsEmptyIntervals(t *testing.T) {
+	agg, err := candle.NewAggregator(10, model.CandleInterval_1s, model.CandleInterval_1m)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	start := time.Unix(1663079280, 0)
+	agg.AddTrade(trade(start, 100, 1))
+	agg.AddTrade(trade(start.Add(300*time.Millisecond), 105, 2))
+	agg.AddTrade(trade(start.Add(600*time.Millisecond), 98, 1))
+	agg.AddTrade(trade(start.Add(3200*time.Millisecond), 110, 1))
+
+	bars, err := agg.GetHistory(model.CandleInterval_1s, start, start.Add(time.Minute))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if len(bars) != 4 {
+		t.Fatalf("expect 4 candles but got %d", len(bars))
+	}
+	first := bars[0]
+	if !first.Open.Equal(decimal.NewFromFloat(100)) || !first.High.Equal(decimal.NewFromFloat(105)) ||
+		!first.Low.Equal(decimal.NewFromFloat(98)) || !first.Close.Equal(decimal.NewFromFloat(98)) ||
+		!first.Volume.Equal(decimal.NewFromFloat(4)) || !first.QuoteVolume.Equal(decimal.NewFromFloat(408)) ||
+		first.TradeCount != 3 {
+		t.Fatalf("wrong first candle: %+v", first)
+	}
+	for _, empty := range bars[1:3] {
+		if empty.TradeCount != 0 || !empty.Open.Equal(decimal.NewFromFloat(98)) || !empty.Volume.IsZero() {
+			t.Fatalf("wrong empty candle: %+v", empty)
+		}
+	}
+	if !bars[3].OpenTime.Time.Equal(start.Add(3*time.Second)) || !bars[3].Close.Equal(decimal.NewFromFloat(110)) {
+		t.Fatalf("wrong last candle: %+v", bars[3])
+	}
+
+	minute, ok, _ := agg.GetCurrent(model.CandleInterval_1m, start.Add(10*time.Second))
+	if !ok || minute.TradeCount != 4 || !minute.High.Equal(decimal.NewFromFloat(110)) {
+		t.Fatalf("wrong minute candle: %+v", minute)
+	}
+	later, ok, _ := agg.GetCurrent(model.CandleInterval_1m, start.Add(2*time.Minute))
+	if !ok || later.TradeCount != 0 || !later.Open.Equal(decimal.NewFromFloat(110)) {
+		t.Fatalf("expect an empty candle after the last trade, but got %+v", later)
+	}
+}
+
+func TestAggregatorRetention(t *testing.T) {
+	agg, _ := candle.NewAggregator(3, model.CandleInterval_1s)
+	start := time.Unix(1663079280, 0)
+	agg.AddTrade(trade(start, 100, 1))
+	agg.AddTrade(trade(start.Add(time.Hour), 101, 1))
+
+	bars, _ := agg.GetHistory(model.CandleInterval_1s, start, start.Add(2*time.Hour))
+	if len(bars) != 4 {
+		t.Fatalf("expect 3 kept candles and the current one, but got %d", len(bars))
+	}
+	if !bars[0].OpenTime.Time.Equal(start.Add(time.Hour - 3*time.Second)) {
+		t.Fatalf("wrong oldest kept candle: %+v", bars[0])
+	}
+}
+
+func TestAggregatorUnknownInterval(t *testing.T) {
+	if _, err := candle.NewAggregator(10, "3m"); !errors.Is(err, candle.ErrUnknownInterval) {
+		t.Fatalf("expect unknown interval error, but got %v", err)
+	}
+}
@@ -0,0 +1,5 @@
+package candle
+
+import "errors"
+
+var ErrUnknownInterval = errors.New("unknown candle interval")
@@ -0,0 +1,134 @@
+package candle
+
+import (
+	"sort"
+	"time"
+
+	"github.com/dylantkx/matching-engine-core/model"
+	"github.com/shopspring/decimal"
+)
+
+var intervalDurations = map[model.CandleInterval]time.Duration{
+	model.CandleInterval_1s: time.Second,
+	model.CandleInterval_1m: time.Minute,
+	model.CandleInterval_5m: 5 * time.Minute,
+	model.CandleInterval_1h: time.Hour,
+	model.CandleInterval_1d: 24 * time.Hour,
+}
+
+// series keeps the candles of one interval. Closed candles are contiguous,
+// with empty intervals filled in, and at most retention of them are kept.
+type series struct {
+	interval  model.CandleInterval
+	duration  time.Duration
+	retention int
+	closed    []model.Candle
+	current   *model.Candle
+}
+
+func newSeries(interval model.CandleInterval, retention int) *series {
+	return &series{
+		interval:  interval,
+		duration:  intervalDurations[interval],
+		retention: retention,
+	}
+}
+
+func (s *series) openTime(t time.Time) time.Time {
+	return t.UTC().Truncate(s.duration)
+}
+
+func (s *series) newCandle(open time.Time, price decimal.Decimal) model.Candle {
+	return model.Candle{
+		Interval:  s.interval,
+		OpenTime:  model.Timestamp{Time: open},
+		CloseTime: model.Timestamp{Time: open.Add(s.duration)},
+		Open:      price,
+		High:      price,
+		Low:       price,
+		Close:     price,
+	}
+}
+
+func (s *series) addTrade(tr model.Trade) {
+	open := s.openTime(tr.EventTime.Time)
+	switch {
+	case s.current == nil:
+		c := s.newCandle(open, tr.Price)
+		s.current = &c
+	case open.After(s.current.OpenTime.Time):
+		s.rollTo(open)
+		c := s.newCandle(open, tr.Price)
+		s.current = &c
+	case open.Before(s.current.OpenTime.Time):
+		// a late trade can only amend a candle that is still kept
+		i := sort.Search(len(s.closed), func(i int) bool {
+			return !s.closed[i].OpenTime.Time.Before(open)
+		})
+		if i < len(s.closed) && s.closed[i].OpenTime.Time.Equal(open) {
+			applyTrade(&s.closed[i], tr)
+		}
+		return
+	}
+	applyTrade(s.current, tr)
+}
+
+// rollTo closes the current candle and fills every empty interval up to, but
+// not including, the one opening at open.
+func (s *series) rollTo(open time.Time) {
+	s.closed = append(s.closed, *s.current)
+	last := s.current.Close
+	next := s.current.OpenTime.Time.Add(s.duration)
+	if gap := int(open.Sub(next) / s.duration); gap > s.retention {
+		next = open.Add(-time.Duration(s.retention) * s.duration)
+	}
+	for ; next.Before(open); next = next.Add(s.duration) {
+		s.closed = append(s.closed, s.newCandle(next, last))
+	}
+	if extra := len(s.closed) - s.retention; extra > 0 {
+		s.closed = append([]model.Candle(nil), s.closed[extra:]...)
+	}
+	s.current = nil
+}
+
+// currentAt returns the candle of the interval containing now.
+func (s *series) currentAt(now time.Time) (model.Candle, bool) {
+	if s.current == nil {
+		return model.Candle{}, false
+	}
+	open := s.openTime(now)
+	if !open.After(s.current.OpenTime.Time) {
+		return *s.current, true
+	}
+	return s.newCandle(open, s.current.Close), true
+}
+
+// history returns the candles opening in [from, to), up to and including the
+// current one.
+func (s *series) history(from, to time.Time) []model.Candle {
+	candles := make([]model.Candle, 0)
+	all := s.closed
+	if s.current != nil {
+		all = append(all[:len(all):len(all)], *s.current)
+	}
+	for _, c := range all {
+		if !c.OpenTime.Time.Before(from) && c.OpenTime.Time.Before(to) {
+			candles = append(candles, c)
+		}
+	}
+	return candles
+}
+
+func applyTrade(c *model.Candle, tr model.Trade) {
+	if c.TradeCount == 0 {
+		c.Open = tr.Price
+		c.High = tr.Price
+		c.Low = tr.Price
+	}
+	c.High = decimal.Max(c.High, tr.Price)
+	c.Low = decimal.Min(c.Low, tr.Price)
+	c.Close = tr.Price
+	c.Volume = c.Volume.Add(tr.Units)
+	c.QuoteVolume = c.QuoteVolume.Add(tr.Units.Mul(tr.Price))
+	c.TradeCount++
+}
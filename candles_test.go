@@ -0,0 +1,39 @@
+package matchingenginecore_test
+
+import (
+	"testing"
+	"time"
+
+	me "github.com/dylantkx/matching-engine-core"
+	"github.com/dylantkx/matching-engine-core/candle"
+	"github.com/dylantkx/matching-engine-core/model"
+	"github.com/shopspring/decimal"
+)
+
+func TestEngineCandles(t *testing.T) {
+	agg, _ := candle.NewAggregator(candle.DefaultRetention)
+	engine := me.NewMatchingEngine(me.WithCandles(agg))
+
+	for i := 1; i <= 2; i++ {
+		engine.ProcessLimitOrder(&model.OrderLimit{
+			ID:    string(rune('0' + i)),
+			Units: decimal.NewFromFloat(1),
+			Price: decimal.NewFromFloat(float64(100 + i)),
+			Side:  model.OrderSide_Sell,
+		})
+	}
+	engine.ProcessMarketOrder(&model.OrderMarket{
+		ID:    "3",
+		Units: decimal.NewFromFloat(2),
+		Side:  model.OrderSide_Buy,
+	})
+
+	c, ok, err := engine.GetCandles().GetCurrent(model.CandleInterval_1d, time.Now())
+	if err != nil || !ok {
+		t.Fatalf("expect a daily candle, but got %v, %v", ok, err)
+	}
+	if c.TradeCount != 2 || !c.Open.Equal(decimal.NewFromFloat(101)) || !c.Close.Equal(decimal.NewFromFloat(102)) ||
+		!c.QuoteVolume.Equal(decimal.NewFromFloat(203)) {
+		t.Fatalf("wrong daily candle: %+v", c)
+	}
+}
@@ -0,0 +1,80 @@
+package matchingenginecore_test
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	me "github.com/dylantkx/matching-engine-core"
+	"github.com/dylantkx/matching-engine-core/model"
+	"github.com/shopspring/decimal"
+)
+
+func TestSnapshotAndDepthUpdatesStayInSync(t *testing.T) {
+	engine := me.NewMatchingEngine()
+	rnd := rand.New(rand.NewSource(1))
+	var results []model.MatchResult
+	submit := func(i int) {
+		side := model.OrderSide_Buy
+		if rnd.Intn(2) == 0 {
+			side = model.OrderSide_Sell
+		}
+		results = append(results, engine.ProcessLimitOrder(&model.OrderLimit{
+			ID:    fmt.Sprintf("%d", i),
+			Units: decimal.NewFromInt(int64(rnd.Intn(5) + 1)),
+			Price: decimal.NewFromInt(int64(95 + rnd.Intn(10))),
+			Side:  side,
+		}))
+	}
+
+	for i := 1; i <= 50; i++ {
+		submit(i)
+	}
+	sn := engine.GetOrderBookFullSnapshot()
+	levels := map[string]decimal.Decimal{}
+	for _, rec := range sn.Buys {
+		levels[model.OrderSide_Buy+rec.Price.String()] = rec.Size
+	}
+	for _, rec := range sn.Sells {
+		levels[model.OrderSide_Sell+rec.Price.String()] = rec.Size
+	}
+
+	for i := 51; i <= 100; i++ {
+		submit(i)
+	}
+	next := sn.Sequence + 1
+	for _, r := range results {
+		for _, u := range r.DepthUpdates {
+			if u.Seq <= sn.Sequence {
+				continue
+			}
+			if u.Seq != next {
+				t.Fatalf("expect update %d but got %d", next, u.Seq)
+			}
+			next++
+			if u.Size.IsZero() {
+				delete(levels, u.Side+u.Price.String())
+			} else {
+				levels[u.Side+u.Price.String()] = u.Size
+			}
+		}
+	}
+
+	final := engine.GetOrderBookFullSnapshot()
+	if final.Sequence != next-1 {
+		t.Fatalf("expect final sequence %d, but got %d", next-1, final.Sequence)
+	}
+	if len(final.Buys)+len(final.Sells) != len(levels) {
+		t.Fatalf("expect %d levels, but got %d", len(final.Buys)+len(final.Sells), len(levels))
+	}
+	for _, rec := range final.Buys {
+		if !levels[model.OrderSide_Buy+rec.Price.String()].Equal(rec.Size) {
+			t.Fatalf("wrong size at buy %s", rec.Price)
+		}
+	}
+	for _, rec := range final.Sells {
+		if !levels[model.OrderSide_Sell+rec.Price.String()].Equal(rec.Size) {
+			t.Fatalf("wrong size at sell %s", rec.Price)
+		}
+	}
+}
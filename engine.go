@@ -1,23 +1,84 @@
 package matchingenginecore
 
 import (
+	"sync"
 	"time"
 
+	"github.com/dylantkx/matching-engine-core/account"
+	"github.com/dylantkx/matching-engine-core/candle"
 	"github.com/dylantkx/matching-engine-core/model"
 	"github.com/dylantkx/matching-engine-core/orderbook"
 	"github.com/shopspring/decimal"
 )
 
 type MatchingEngine struct {
-	book orderbook.Book
+	symbol    string
+	book      orderbook.Book
+	stops     *stopBook
+	expiries  *expiryBook
+	orders    map[string]model.OrderLimit
+	states    map[string]*OrderState
+	now       time.Time
+	lastPrice decimal.Decimal
+	rules     model.InstrumentRules
+	scales    orderbook.Scales
+	allocator orderbook.Allocator
+	journal   Journal
+	seq       uint64
+
+	selfTradePrevention model.SelfTradePrevention
+	depthUpdates        []model.DepthUpdate
+	sinks               []EventSink
+	candles             *candle.Aggregator
+	accounts            *account.Accounts
+	baseAsset           string
+	quoteAsset          string
+	fees                *feeLedger
+	ticker              *rollingTicker
+	session             model.SessionState
+	band                model.PriceBand
+	breaker             *circuitBreaker
+	auction             bool
+	indicative          model.AuctionIndicative
+	events              []model.Event
+	mu                  sync.Mutex
+}
+
+func NewMatchingEngine(opts ...Option) *MatchingEngine {
+	me := &MatchingEngine{
+		scales:   orderbook.DefaultScales,
+		stops:    newStopBook(),
+		expiries: newExpiryBook(),
+		orders:   make(map[string]model.OrderLimit),
+		states:   make(map[string]*OrderState),
+		ticker:   newRollingTicker(defaultTickerWindow),
+		session:  model.SessionState_Open,
+	}
+	for _, opt := range opts {
+		opt(me)
+	}
+	me.book = orderbook.NewBook(me.bookOptions()...)
+	me.book.SetDepthListener(me.addDepthUpdate)
+	return me
 }
 
-func NewMatchingEngine() *MatchingEngine {
-	return &MatchingEngine{
-		book: orderbook.NewBook(),
+func (me *MatchingEngine) bookOptions() []orderbook.Option {
+	return []orderbook.Option{
+		orderbook.WithScales(me.scales),
+		orderbook.WithAllocator(me.allocator),
 	}
 }
 
+func (me *MatchingEngine) GetSymbol() string {
+	return me.symbol
+}
+
+// GetCandles returns the candles built from the trades of the engine, or nil
+// if it was not created with WithCandles.
+func (me *MatchingEngine) GetCandles() *candle.Aggregator {
+	return me.candles
+}
+
 func (me *MatchingEngine) GetHighestBuyPrice() decimal.Decimal {
 	best := me.book.GetHighestBuy()
 	if best == nil {
@@ -34,6 +95,22 @@ func (me *MatchingEngine) GetLowestSellPrice() decimal.Decimal {
 	return best.Price
 }
 
+func (me *MatchingEngine) GetLastTradePrice() decimal.Decimal {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	return me.lastPrice
+}
+
+// GetTicker returns the statistics of the trades of the last 24 hours, or of
+// the window set with WithTickerWindow.
+func (me *MatchingEngine) GetTicker() model.Ticker {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	tk := me.ticker.ticker(time.Now())
+	tk.Symbol = me.symbol
+	return tk
+}
+
 func (me *MatchingEngine) GetOrderBookFullSnapshot() *orderbook.BookSnapshot {
 	return me.book.GetFullSnapshot()
 }
@@ -51,40 +128,496 @@ func (me *MatchingEngine) GetTotalSellUnitsToPrice(price decimal.Decimal) decima
 }
 
 func (me *MatchingEngine) ProcessLimitOrder(order *model.OrderLimit) model.MatchResult {
-	if order.Side == model.OrderSide_Buy {
-		return me.processLimitBuyOrder(order)
-	}
-	return me.processLimitSellOrder(order)
+	return me.execute(Command{Limit: order}, time.Now()).Result
 }
 
 func (me *MatchingEngine) ProcessMarketOrder(order *model.OrderMarket) model.MatchResult {
-	if order.Side == model.OrderSide_Buy {
-		return me.processMarketBuyOrder(order)
-	}
-	return me.processMarketSellOrder(order)
+	return me.execute(Command{Market: order}, time.Now()).Result
+}
+
+// ProcessStopOrder parks a stop order until a trade crosses its stop price.
+// If the last trade price already crosses it, the order is released at once.
+// Fills of released stop orders, including any stop orders they trigger in
+// turn, are reported in the result of the order whose trades triggered them.
+func (me *MatchingEngine) ProcessStopOrder(order *model.OrderStop) model.MatchResult {
+	return me.execute(Command{Stop: order}, time.Now()).Result
 }
 
+// CancelOrder cancels a resting order. Its execution report is published to
+// the event sinks; CancelOrderByID returns it as well.
 func (me *MatchingEngine) CancelOrder(order model.Order) ([]model.OrderCancellation, error) {
-	return me.book.CancelOrder(order)
+	cr := me.execute(Command{CancelOrder: &order}, time.Now())
+	return cr.Result.Cancellations, cr.Err
+}
+
+// CancelOrderByID cancels a resting order or a pending stop order.
+func (me *MatchingEngine) CancelOrderByID(id string) (model.MatchResult, error) {
+	cr := me.execute(Command{Cancel: &model.CancelRequest{Symbol: me.symbol, OrderID: id}}, time.Now())
+	return cr.Result, cr.Err
+}
+
+// ExpireOrders cancels every resting good-till-date order whose expire time
+// is not after now.
+func (me *MatchingEngine) ExpireOrders(now time.Time) model.MatchResult {
+	return me.execute(Command{Expire: true}, now).Result
+}
+
+// execute journals cmd and applies it with the engine clock set to now. A
+// command that cannot be journaled is not applied: new orders are rejected
+// and the journal error is returned.
+func (me *MatchingEngine) execute(cmd Command, now time.Time) (cr CommandResult) {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	me.now = now
+	cr.Symbol = me.symbol
+	defer me.flushEvents()
+	if err := me.journalCommand(cmd); err != nil {
+		cr.Err = err
+		me.rejectCommand(&cr.Result, cmd, model.RejectReason_NotJournaled)
+		return
+	}
+	return me.apply(cmd)
+}
+
+func (me *MatchingEngine) apply(cmd Command) (cr CommandResult) {
+	cr.Symbol = me.symbol
+	resumed := me.endCoolingOff()
+	if reason, err := me.checkSession(cmd); err != nil {
+		if reason != "" {
+			me.rejectCommand(&cr.Result, cmd, reason)
+		}
+		cr.Err = err
+		return
+	}
+	if id := newOrderID(cmd); id != "" && me.states[id] != nil {
+		// a working order is only changed through AmendOrder
+		me.rejectCommand(&cr.Result, cmd, model.RejectReason_DuplicateOrderID)
+		return
+	}
+	switch {
+	case cmd.Limit != nil:
+		cr.Result = me.processLimitOrder(cmd.Limit)
+		me.releaseStops(&cr.Result, cr.Result.Trades)
+	case cmd.Market != nil:
+		cr.Result = me.processMarketOrder(cmd.Market)
+		me.releaseStops(&cr.Result, cr.Result.Trades)
+	case cmd.Stop != nil:
+		cr.Result = me.submitStopOrder(cmd.Stop)
+	case cmd.Amend != nil:
+		var ack model.OrderAmendAck
+		cr.Result, ack, cr.Err = me.amendOrder(cmd.Amend)
+		if cr.Err == nil {
+			cr.AmendAck = &ack
+		}
+	case cmd.Cancel != nil:
+		cr.Result, cr.Err = me.cancelOrderByID(cmd.Cancel.OrderID)
+	case cmd.CancelOrder != nil:
+		cr.Result, cr.Err = me.cancelOrder(*cmd.CancelOrder)
+	case cmd.Expire:
+		cr.Result = me.expireOrders(me.now)
+	case cmd.Session != nil:
+		cr.Result, cr.Err = me.setSession(cmd.Session.State, "")
+	case cmd.StartAuction:
+		me.auction = true
+	case cmd.Uncross && me.session == model.SessionState_PreOpen:
+		cr.Result, cr.Err = me.setSession(model.SessionState_Open, "")
+	case cmd.Uncross:
+		cr.Result = me.uncross()
+		me.releaseStops(&cr.Result, cr.Result.Trades)
+	default:
+		cr.Err = ErrEmptyCommand
+	}
+	if me.auction {
+		me.updateIndicative(&cr.Result)
+	}
+	if resumed.SessionState != nil {
+		resumed.Append(cr.Result)
+		cr.Result = resumed
+	}
+	cr.Result.DepthUpdates = append(cr.Result.DepthUpdates, me.depthUpdates...)
+	me.depthUpdates = nil
+	return
+}
+
+// addDepthUpdate collects the depth updates of the book while a command is
+// applied, to be handed out with its result.
+func (me *MatchingEngine) addDepthUpdate(u model.DepthUpdate) {
+	me.depthUpdates = append(me.depthUpdates, u)
+	me.publish(model.Event{Type: model.EventType_DepthUpdate, DepthUpdate: &u})
+}
+
+// newOrderID returns the ID of the order carried by cmd, if any.
+func newOrderID(cmd Command) string {
+	switch {
+	case cmd.Limit != nil:
+		return cmd.Limit.ID
+	case cmd.Market != nil:
+		return cmd.Market.ID
+	case cmd.Stop != nil:
+		return cmd.Stop.ID
+	}
+	return ""
+}
+
+// rejectCommand rejects the order carried by cmd, if any.
+func (me *MatchingEngine) rejectCommand(r *model.MatchResult, cmd Command, reason model.RejectReason) {
+	var id, ownerID string
+	var side model.OrderSide
+	var price, units decimal.Decimal
+	switch {
+	case cmd.Limit != nil:
+		id, ownerID, side, price, units = cmd.Limit.ID, cmd.Limit.OwnerID, cmd.Limit.Side, cmd.Limit.Price, cmd.Limit.Units
+	case cmd.Market != nil:
+		id, ownerID, side, units = cmd.Market.ID, cmd.Market.OwnerID, cmd.Market.Side, cmd.Market.Units
+	case cmd.Stop != nil:
+		id, ownerID, side, price, units = cmd.Stop.ID, cmd.Stop.OwnerID, cmd.Stop.Side, cmd.Stop.Price, cmd.Stop.Units
+	default:
+		return
+	}
+	if _, live := me.states[id]; live {
+		// never drop the state of an order already working under the same ID
+		rej := model.OrderRejection{OrderID: id, Reason: reason}
+		r.Rejections = append(r.Rejections, rej)
+		me.publish(model.Event{Type: model.EventType_Rejection, Rejection: &rej})
+		return
+	}
+	me.trackOrder(id, ownerID, side, price, units)
+	me.addRejection(r, model.OrderRejection{OrderID: id, Reason: reason})
+}
+
+func (me *MatchingEngine) submitStopOrder(order *model.OrderStop) (r model.MatchResult) {
+	s, _ := me.trackOrder(order.ID, order.OwnerID, order.Side, order.Price, order.Units)
+	if reason := me.checkStopOrder(order); reason != "" {
+		me.addRejection(&r, model.OrderRejection{
+			OrderID: order.ID,
+			Reason:  reason,
+		})
+		return
+	}
+	me.reportNew(&r, s)
+	if !me.auction && me.lastPrice.IsPositive() && order.IsTriggeredBy(me.lastPrice) {
+		me.report(&r, s, model.ExecType_Triggered, s.liveStatus(), "")
+		r.Append(me.processStopOrder(order))
+		me.releaseStops(&r, r.Trades)
+		return
+	}
+	stop := *order
+	me.stops.Add(&stop)
+	return
+}
+
+func (me *MatchingEngine) cancelOrder(order model.Order) (r model.MatchResult, err error) {
+	cancels, err := me.book.CancelOrder(order)
+	if err != nil {
+		return
+	}
+	me.expiries.Remove(order.ID)
+	delete(me.orders, order.ID)
+	for _, c := range cancels {
+		me.addCancellation(&r, c)
+	}
+	return
+}
+
+func (me *MatchingEngine) cancelOrderByID(id string) (r model.MatchResult, err error) {
+	if stop, ok := me.stops.Remove(id); ok {
+		me.addCancellation(&r, model.OrderCancellation{
+			OrderID: stop.ID,
+			Units:   stop.Units,
+			Reason:  model.CancelReason_Requested,
+		})
+		return
+	}
+	cancels, err := me.book.CancelOrderByID(id)
+	if err != nil {
+		return
+	}
+	me.expiries.Remove(id)
+	delete(me.orders, id)
+	for _, c := range cancels {
+		me.addCancellation(&r, c)
+	}
+	return
+}
+
+func (me *MatchingEngine) expireOrders(now time.Time) (r model.MatchResult) {
+	for _, id := range me.expiries.PopExpired(now) {
+		delete(me.orders, id)
+		cancels, err := me.book.CancelOrderByID(id)
+		if err != nil {
+			continue
+		}
+		for _, c := range cancels {
+			c.Reason = model.CancelReason_Expired
+			me.addCancellation(&r, c)
+		}
+	}
+	return
+}
+
+func (me *MatchingEngine) processLimitOrder(order *model.OrderLimit) (r model.MatchResult) {
+	now := me.now
+	s, isNew := me.trackOrder(order.ID, order.OwnerID, order.Side, order.Price, order.Units)
+	reason := me.checkLimitOrder(order)
+	if reason == "" && me.auction && (order.TimeInForce == model.TimeInForce_IOC || order.TimeInForce == model.TimeInForce_FOK) {
+		reason = model.RejectReason_AuctionInProgress
+	}
+	if reason != "" {
+		me.addRejection(&r, model.OrderRejection{
+			OrderID: order.ID,
+			Reason:  reason,
+		})
+		return
+	}
+	if order.PostOnly && !me.auction {
+		var ok bool
+		if order, ok = me.applyPostOnly(order); !ok {
+			me.addRejection(&r, model.OrderRejection{
+				OrderID: order.ID,
+				Reason:  model.RejectReason_PostOnlyWouldCross,
+			})
+			return
+		}
+		s.Price = order.Price
+	}
+	// matchPrice is the worst price the order may trade at
+	matchPrice := order.Price
+	band, breach := me.checkPriceBand(order.Side, order.Units, &order.Price)
+	if band != nil && !me.auction {
+		if breach && me.band.Mode == model.PriceBandMode_Reject {
+			me.addRejection(&r, model.OrderRejection{
+				OrderID: order.ID,
+				Reason:  model.RejectReason_PriceBand,
+			})
+			return
+		}
+		if order.Side == model.OrderSide_Buy {
+			matchPrice = decimal.Min(matchPrice, *band)
+		} else {
+			matchPrice = decimal.Max(matchPrice, *band)
+		}
+	}
+	if !me.holdFunds(s, lockedFor(order.Side, order.Units, order.Price)) {
+		me.addRejection(&r, model.OrderRejection{
+			OrderID: order.ID,
+			Reason:  model.RejectReason_InsufficientFunds,
+		})
+		return
+	}
+	if isNew {
+		me.reportNew(&r, s)
+	}
+	switch order.TimeInForce {
+	case model.TimeInForce_FOK:
+		var available decimal.Decimal
+		opts := me.clearOptions(order.OwnerID, &matchPrice)
+		if order.Side == model.OrderSide_Buy {
+			available = me.book.GetSellSideFillableUnits(order.Units, opts)
+		} else {
+			available = me.book.GetBuySideFillableUnits(order.Units, opts)
+		}
+		if available.LessThan(order.Units) {
+			me.addCancellation(&r, model.OrderCancellation{
+				OrderID: order.ID,
+				Units:   order.Units,
+				Reason:  model.CancelReason_FillOrKill,
+			})
+			return
+		}
+	case model.TimeInForce_GTD:
+		if !order.ExpireTime.Time.Truncate(time.Second).After(now) {
+			me.addCancellation(&r, model.OrderCancellation{
+				OrderID: order.ID,
+				Units:   order.Units,
+				Reason:  model.CancelReason_Expired,
+			})
+			return
+		}
+	}
+	if me.auction {
+		me.restLimitOrder(order, order.Units)
+		return
+	}
+
+	var mr model.MatchResult
+	var remainingUnits decimal.Decimal
+	if order.Side == model.OrderSide_Buy {
+		mr, remainingUnits = me.processLimitBuyOrder(order, matchPrice, now)
+	} else {
+		mr, remainingUnits = me.processLimitSellOrder(order, matchPrice, now)
+	}
+	r.Append(mr)
+	if !remainingUnits.IsPositive() {
+		return
+	}
+	if breach {
+		me.addCancellation(&r, model.OrderCancellation{
+			OrderID: order.ID,
+			Units:   remainingUnits,
+			Reason:  model.CancelReason_PriceBand,
+		})
+		return
+	}
+
+	switch order.TimeInForce {
+	case model.TimeInForce_IOC:
+		me.addCancellation(&r, model.OrderCancellation{
+			OrderID: order.ID,
+			Units:   remainingUnits,
+			Reason:  model.CancelReason_ImmediateOrCancel,
+		})
+	case model.TimeInForce_FOK:
+		// liquidity is checked upfront, but a fill-or-kill order must never rest
+		me.addCancellation(&r, model.OrderCancellation{
+			OrderID: order.ID,
+			Units:   remainingUnits,
+			Reason:  model.CancelReason_FillOrKill,
+		})
+	default:
+		me.restLimitOrder(order, remainingUnits)
+	}
+	return
 }
 
-func (me *MatchingEngine) processLimitBuyOrder(order *model.OrderLimit) (r model.MatchResult) {
-	if me.book.GetLowestSell() == nil || me.book.GetLowestSell().Price.GreaterThan(order.Price) {
-		me.book.AddBuyOrder(model.Order{
-			ID:    order.ID,
-			Units: order.Units,
-			Price: order.Price,
-			Side:  model.OrderSide_Buy,
+func (me *MatchingEngine) processMarketOrder(order *model.OrderMarket) (r model.MatchResult) {
+	s, isNew := me.trackOrder(order.ID, order.OwnerID, order.Side, decimal.Zero, order.Units)
+	if isNew {
+		s.LeavesQuote = order.QuoteAmount
+	}
+	reason := me.checkMarketOrder(order)
+	if reason == "" && me.auction {
+		reason = model.RejectReason_AuctionInProgress
+	}
+	var band *decimal.Decimal
+	var breach bool
+	if order.QuoteAmount.IsZero() {
+		band, breach = me.checkPriceBand(order.Side, order.Units, nil)
+	} else {
+		band, breach = me.checkQuoteBand(order.QuoteAmount)
+	}
+	if reason == "" && breach && me.band.Mode == model.PriceBandMode_Reject {
+		reason = model.RejectReason_PriceBand
+	}
+	unfilled := model.CancelReason_Unfilled
+	if breach {
+		unfilled = model.CancelReason_PriceBand
+	}
+	limit := band
+	if protection := me.protectionPrice(order); protection != nil && tighterPrice(order.Side, protection, band) == protection {
+		limit = protection
+		if me.hasLiquidityBeyond(order.Side, *protection) {
+			unfilled = model.CancelReason_Slippage
+		}
+	}
+	if reason == "" && !me.holdMarketFunds(s, order, limit) {
+		reason = model.RejectReason_InsufficientFunds
+	}
+	if reason != "" {
+		me.addRejection(&r, model.OrderRejection{
+			OrderID: order.ID,
+			Reason:  reason,
 		})
 		return
 	}
+	if isNew {
+		me.reportNew(&r, s)
+	}
+	if order.QuoteAmount.IsPositive() {
+		r.Append(me.processMarketQuoteOrder(order, limit, unfilled))
+		return
+	}
 
-	now := time.Now()
-	remainingUnits := order.Units.Copy()
+	var mr model.MatchResult
+	var remainingUnits decimal.Decimal
+	if order.Side == model.OrderSide_Buy {
+		mr, remainingUnits = me.processMarketBuyOrder(order, limit)
+	} else {
+		mr, remainingUnits = me.processMarketSellOrder(order, limit)
+	}
+	r.Append(mr)
+	if !remainingUnits.IsPositive() {
+		return
+	}
+	if order.MarketToLimit && len(mr.Trades) > 0 {
+		price := mr.Trades[len(mr.Trades)-1].Price
+		if me.holdFunds(s, lockedFor(order.Side, remainingUnits, price)) {
+			s.Price = price
+			me.report(&r, s, model.ExecType_Restated, s.liveStatus(), "")
+			me.restLimitOrder(&model.OrderLimit{
+				ID:      order.ID,
+				OwnerID: order.OwnerID,
+				Units:   remainingUnits,
+				Price:   price,
+				Side:    order.Side,
+			}, remainingUnits)
+			return
+		}
+	}
+	me.addCancellation(&r, model.OrderCancellation{
+		OrderID: order.ID,
+		Units:   remainingUnits,
+		Reason:  unfilled,
+	})
+	return
+}
+
+func (me *MatchingEngine) processStopOrder(order *model.OrderStop) model.MatchResult {
+	if order.Type == model.OrderType_StopLimit {
+		return me.processLimitOrder(&model.OrderLimit{
+			ID:      order.ID,
+			OwnerID: order.OwnerID,
+			Units:   order.Units,
+			Price:   order.Price,
+			Side:    order.Side,
+		})
+	}
+	return me.processMarketOrder(&model.OrderMarket{
+		ID:      order.ID,
+		OwnerID: order.OwnerID,
+		Units:   order.Units,
+		Side:    order.Side,
+	})
+}
+
+// releaseStops records the last trade price and processes every stop order
+// triggered by trades, appending their outcome to r. Stops are released in
+// waves: all orders triggered by one set of trades are processed in the order
+// they were submitted, then the trades of that wave are checked for further
+// triggers, until a wave produces no new trades.
+func (me *MatchingEngine) releaseStops(r *model.MatchResult, trades []model.Trade) {
+	for len(trades) > 0 {
+		me.lastPrice = trades[len(trades)-1].Price
+		if me.tripCircuitBreaker(r, trades) || me.stops.Len() == 0 {
+			return
+		}
+		low, high := trades[0].Price, trades[0].Price
+		for _, tr := range trades[1:] {
+			low = decimal.Min(low, tr.Price)
+			high = decimal.Max(high, tr.Price)
+		}
+		trades = nil
+		for _, stop := range me.stops.PopTriggered(low, high) {
+			if s := me.states[stop.ID]; s != nil {
+				me.report(r, s, model.ExecType_Triggered, s.liveStatus(), "")
+			}
+			sr := me.processStopOrder(stop)
+			r.Append(sr)
+			trades = append(trades, sr.Trades...)
+		}
+	}
+}
+
+// processLimitBuyOrder matches order against sells priced up to price.
+func (me *MatchingEngine) processLimitBuyOrder(order *model.OrderLimit, price decimal.Decimal, now time.Time) (r model.MatchResult, remainingUnits decimal.Decimal) {
+	remainingUnits = order.Units.Copy()
+	if me.book.GetLowestSell() == nil || me.book.GetLowestSell().Price.GreaterThan(price) {
+		return
+	}
 
-	matchedOrders := me.book.ClearSellSideByUnitsAndPrice(order.Units.Copy(), order.Price.Copy())
-	for _, o := range matchedOrders {
-		r.Trades = append(r.Trades, model.Trade{
+	cr := me.book.ClearSellSide(order.Units.Copy(), me.clearOptions(order.OwnerID, &price))
+	for _, o := range cr.ClearedOrders {
+		me.addTrade(&r, model.Trade{
+			Symbol:       me.symbol,
 			BuyOrderID:   order.ID,
 			SellOrderID:  o.ID,
 			Units:        o.Units,
@@ -94,36 +627,22 @@ func (me *MatchingEngine) processLimitBuyOrder(order *model.OrderLimit) (r model
 		})
 		remainingUnits = remainingUnits.Sub(o.Units)
 	}
-
-	// push the order into buy book if any remaining
-	if remainingUnits.IsPositive() {
-		me.book.AddBuyOrder(model.Order{
-			ID:    order.ID,
-			Units: remainingUnits,
-			Price: order.Price,
-			Side:  model.OrderSide_Buy,
-		})
-	}
+	remainingUnits = me.applySelfTradePrevention(&r, order.ID, remainingUnits, cr)
+	me.forgetInactiveOrders(cr)
 	return
 }
 
-func (me *MatchingEngine) processLimitSellOrder(order *model.OrderLimit) (r model.MatchResult) {
-	if me.book.GetHighestBuy() == nil || me.book.GetHighestBuy().Price.LessThan(order.Price) {
-		me.book.AddSellOrder(model.Order{
-			ID:    order.ID,
-			Units: order.Units,
-			Price: order.Price,
-			Side:  model.OrderSide_Sell,
-		})
+// processLimitSellOrder matches order against buys priced down to price.
+func (me *MatchingEngine) processLimitSellOrder(order *model.OrderLimit, price decimal.Decimal, now time.Time) (r model.MatchResult, remainingUnits decimal.Decimal) {
+	remainingUnits = order.Units.Copy()
+	if me.book.GetHighestBuy() == nil || me.book.GetHighestBuy().Price.LessThan(price) {
 		return
 	}
 
-	now := time.Now()
-	remainingUnits := order.Units.Copy()
-
-	matchedOrders := me.book.ClearBuySideByUnitsAndPrice(order.Units.Copy(), order.Price.Copy())
-	for _, o := range matchedOrders {
-		r.Trades = append(r.Trades, model.Trade{
+	cr := me.book.ClearBuySide(order.Units.Copy(), me.clearOptions(order.OwnerID, &price))
+	for _, o := range cr.ClearedOrders {
+		me.addTrade(&r, model.Trade{
+			Symbol:       me.symbol,
 			BuyOrderID:   o.ID,
 			SellOrderID:  order.ID,
 			Units:        o.Units,
@@ -133,34 +652,121 @@ func (me *MatchingEngine) processLimitSellOrder(order *model.OrderLimit) (r mode
 		})
 		remainingUnits = remainingUnits.Sub(o.Units)
 	}
+	remainingUnits = me.applySelfTradePrevention(&r, order.ID, remainingUnits, cr)
+	me.forgetInactiveOrders(cr)
+	return
+}
 
-	// push the order into sell book if any remaining
-	if remainingUnits.IsPositive() {
-		me.book.AddSellOrder(model.Order{
-			ID:    order.ID,
-			Units: remainingUnits,
-			Price: order.Price,
-			Side:  model.OrderSide_Sell,
-		})
+// applyPostOnly returns the order to process in place of a post-only order,
+// or false if the order would take liquidity and cannot be slid.
+func (me *MatchingEngine) applyPostOnly(order *model.OrderLimit) (*model.OrderLimit, bool) {
+	var price decimal.Decimal
+	if order.Side == model.OrderSide_Buy {
+		best := me.book.GetLowestSell()
+		if best == nil || best.Price.GreaterThan(order.Price) {
+			return order, true
+		}
+		price = best.Price.Sub(me.rules.TickSize)
+	} else {
+		best := me.book.GetHighestBuy()
+		if best == nil || best.Price.LessThan(order.Price) {
+			return order, true
+		}
+		price = best.Price.Add(me.rules.TickSize)
 	}
-	return
+	if order.PostOnlyMode != model.PostOnlyMode_Slide || !me.rules.TickSize.IsPositive() || !price.IsPositive() {
+		return order, false
+	}
+	slid := *order
+	slid.Price = price
+	return &slid, true
+}
+
+func (me *MatchingEngine) clearOptions(ownerID string, price *decimal.Decimal) orderbook.ClearOptions {
+	return orderbook.ClearOptions{
+		Price:               price,
+		OwnerID:             ownerID,
+		SelfTradePrevention: me.selfTradePrevention,
+	}
+}
+
+// applySelfTradePrevention reports orders cancelled by self-trade prevention
+// and returns the units of the incoming order left after its own cancellation.
+func (me *MatchingEngine) applySelfTradePrevention(r *model.MatchResult, orderID string, remainingUnits decimal.Decimal, cr orderbook.ClearResult) decimal.Decimal {
+	for _, c := range cr.Cancellations {
+		me.addCancellation(r, c)
+	}
+	if !cr.TakerCancelledUnits.IsPositive() {
+		return remainingUnits
+	}
+	me.addCancellation(r, model.OrderCancellation{
+		OrderID: orderID,
+		Units:   cr.TakerCancelledUnits,
+		Reason:  model.CancelReason_SelfTrade,
+	})
+	return remainingUnits.Sub(cr.TakerCancelledUnits)
 }
 
-func (me *MatchingEngine) processMarketBuyOrder(order *model.OrderMarket) (r model.MatchResult) {
+// forgetInactiveOrders drops the resting limit orders touched by a clear that
+// are no longer on the book.
+func (me *MatchingEngine) forgetInactiveOrders(cr orderbook.ClearResult) {
+	for _, o := range cr.ClearedOrders {
+		if _, ok := me.book.GetOrder(o.ID); !ok {
+			delete(me.orders, o.ID)
+		}
+	}
+	for _, c := range cr.Cancellations {
+		if _, ok := me.book.GetOrder(c.OrderID); !ok {
+			delete(me.orders, c.OrderID)
+		}
+	}
+}
+
+// restLimitOrder pushes the unfilled units of a limit order into the book.
+func (me *MatchingEngine) restLimitOrder(order *model.OrderLimit, units decimal.Decimal) {
+	o := model.Order{
+		ID:           order.ID,
+		OwnerID:      order.OwnerID,
+		Units:        units,
+		Price:        order.Price,
+		Side:         order.Side,
+		DisplayUnits: order.DisplayUnits,
+	}
+	var err error
+	if order.Side == model.OrderSide_Buy {
+		err = me.book.AddBuyOrder(o)
+	} else {
+		err = me.book.AddSellOrder(o)
+	}
+	if err != nil {
+		// not reached, as orders finer than the scales are rejected upfront
+		return
+	}
+	if s := me.states[order.ID]; s != nil {
+		me.releaseFunds(s, lockedFor(order.Side, units, order.Price))
+	}
+	me.orders[order.ID] = *order
+	if order.TimeInForce == model.TimeInForce_GTD {
+		me.expiries.Add(order.ID, order.ExpireTime.Time)
+	} else {
+		me.expiries.Remove(order.ID)
+	}
+}
+
+// processMarketBuyOrder matches order against the book, never beyond limit if
+// one is given, and returns the units left.
+func (me *MatchingEngine) processMarketBuyOrder(order *model.OrderMarket, limit *decimal.Decimal) (r model.MatchResult, remainingUnits decimal.Decimal) {
+	remainingUnits = order.Units.Copy()
 	if me.book.GetLowestSell() == nil {
-		r.Cancellations = append(r.Cancellations, model.OrderCancellation{
-			OrderID: order.ID,
-			Units:   order.Units,
-		})
 		return
 	}
 
-	now := time.Now()
-	remainingUnits := order.Units.Copy()
+	now := me.now
 
-	matchedOrders := me.book.ClearSellSideByUnits(order.Units.Copy())
-	for _, o := range matchedOrders {
-		r.Trades = append(r.Trades, model.Trade{
+	cr := me.book.ClearSellSide(order.Units.Copy(), me.clearOptions(order.OwnerID, limit))
+	for _, o := range cr.ClearedOrders {
+		me.addTrade(&r, model.Trade{
+			Symbol:       me.symbol,
 			BuyOrderID:   order.ID,
 			SellOrderID:  o.ID,
 			Units:        o.Units,
@@ -170,31 +776,25 @@ func (me *MatchingEngine) processMarketBuyOrder(order *model.OrderMarket) (r mod
 		})
 		remainingUnits = remainingUnits.Sub(o.Units)
 	}
-
-	if remainingUnits.IsPositive() {
-		r.Cancellations = append(r.Cancellations, model.OrderCancellation{
-			OrderID: order.ID,
-			Units:   remainingUnits,
-		})
-	}
+	remainingUnits = me.applySelfTradePrevention(&r, order.ID, remainingUnits, cr)
+	me.forgetInactiveOrders(cr)
 	return
 }
 
-func (me *MatchingEngine) processMarketSellOrder(order *model.OrderMarket) (r model.MatchResult) {
+// processMarketSellOrder matches order against the book, never beyond limit if
+// one is given, and returns the units left.
+func (me *MatchingEngine) processMarketSellOrder(order *model.OrderMarket, limit *decimal.Decimal) (r model.MatchResult, remainingUnits decimal.Decimal) {
+	remainingUnits = order.Units.Copy()
 	if me.book.GetHighestBuy() == nil {
-		r.Cancellations = append(r.Cancellations, model.OrderCancellation{
-			OrderID: order.ID,
-			Units:   order.Units,
-		})
 		return
 	}
 
-	now := time.Now()
-	remainingUnits := order.Units.Copy()
+	now := me.now
 
-	matchedOrders := me.book.ClearBuySideByUnits(order.Units.Copy())
-	for _, o := range matchedOrders {
-		r.Trades = append(r.Trades, model.Trade{
+	cr := me.book.ClearBuySide(order.Units.Copy(), me.clearOptions(order.OwnerID, limit))
+	for _, o := range cr.ClearedOrders {
+		me.addTrade(&r, model.Trade{
+			Symbol:       me.symbol,
 			BuyOrderID:   o.ID,
 			SellOrderID:  order.ID,
 			Units:        o.Units,
@@ -204,12 +804,7 @@ func (me *MatchingEngine) processMarketSellOrder(order *model.OrderMarket) (r mo
 		})
 		remainingUnits = remainingUnits.Sub(o.Units)
 	}
-
-	if remainingUnits.IsPositive() {
-		r.Cancellations = append(r.Cancellations, model.OrderCancellation{
-			OrderID: order.ID,
-			Units:   remainingUnits,
-		})
-	}
+	remainingUnits = me.applySelfTradePrevention(&r, order.ID, remainingUnits, cr)
+	me.forgetInactiveOrders(cr)
 	return
 }
@@ -1,6 +1,7 @@
 package matchingenginecore_test
 
 import (
+	"errors"
 	"fmt"
 	"math/rand"
 	"sync"
@@ -9,6 +10,7 @@ import (
 
 	me "github.com/dylantkx/matching-engine-core"
 	"github.com/dylantkx/matching-engine-core/model"
+	"github.com/dylantkx/matching-engine-core/orderbook"
 	"github.com/shopspring/decimal"
 )
 
@@ -522,6 +524,293 @@ func TestCancelOrder(t *testing.T) {
 	}
 }
 
+func TestCancelOrderByID(t *testing.T) {
+	engine := me.NewMatchingEngine()
+
+	engine.ProcessLimitOrder(&model.OrderLimit{
+		ID:    "1",
+		Units: decimal.NewFromFloat(2),
+		Price: decimal.NewFromFloat(100),
+		Side:  model.OrderSide_Sell,
+	})
+	engine.ProcessMarketOrder(&model.OrderMarket{
+		ID:    "2",
+		Units: decimal.NewFromFloat(0.5),
+		Side:  model.OrderSide_Buy,
+	})
+
+	r, err := engine.CancelOrderByID("1")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	cancels := r.Cancellations
+	if len(cancels) != 1 || cancels[0].OrderID != "1" || !cancels[0].Units.Equal(decimal.NewFromFloat(1.5)) {
+		t.Fatalf("wrong cancel output: %+v", cancels)
+	}
+	if p := engine.GetLowestSellPrice(); !p.IsZero() {
+		t.Fatalf("expect lowest sell to be 0, but got %s", p)
+	}
+
+	_, err = engine.CancelOrderByID("1")
+	if !errors.Is(err, orderbook.ErrOrderNotFound) {
+		t.Fatalf("expect order not found error, but got %v", err)
+	}
+}
+
+func TestLimitOrderImmediateOrCancel(t *testing.T) {
+	engine := me.NewMatchingEngine()
+
+	engine.ProcessLimitOrder(&model.OrderLimit{
+		ID:    "1",
+		Units: decimal.NewFromFloat(1),
+		Price: decimal.NewFromFloat(100),
+		Side:  model.OrderSide_Sell,
+	})
+
+	r := engine.ProcessLimitOrder(&model.OrderLimit{
+		ID:          "2",
+		Units:       decimal.NewFromFloat(3),
+		Price:       decimal.NewFromFloat(100),
+		Side:        model.OrderSide_Buy,
+		TimeInForce: model.TimeInForce_IOC,
+	})
+	if len(r.Trades) != 1 {
+		t.Fatalf("expect 1 trade but got %d", len(r.Trades))
+	}
+	if len(r.Cancellations) != 1 {
+		t.Fatalf("expect 1 cancel but got %d", len(r.Cancellations))
+	}
+	c := r.Cancellations[0]
+	if c.OrderID != "2" || !c.Units.Equal(decimal.NewFromFloat(2)) || c.Reason != model.CancelReason_ImmediateOrCancel {
+		t.Fatalf("wrong cancel output: %+v", c)
+	}
+	if p := engine.GetHighestBuyPrice(); !p.IsZero() {
+		t.Fatalf("expect IOC remainder not to rest, but highest buy is %s", p)
+	}
+}
+
+func TestLimitOrderFillOrKill(t *testing.T) {
+	engine := me.NewMatchingEngine()
+
+	sellOrders := []model.OrderLimit{
+		{
+			ID:    "1",
+			Units: decimal.NewFromFloat(1),
+			Price: decimal.NewFromFloat(100),
+			Side:  model.OrderSide_Sell,
+		},
+		{
+			ID:    "2",
+			Units: decimal.NewFromFloat(1),
+			Price: decimal.NewFromFloat(110),
+			Side:  model.OrderSide_Sell,
+		},
+	}
+	for _, ord := range sellOrders {
+		engine.ProcessLimitOrder(&ord)
+	}
+
+	r := engine.ProcessLimitOrder(&model.OrderLimit{
+		ID:          "3",
+		Units:       decimal.NewFromFloat(2),
+		Price:       decimal.NewFromFloat(105),
+		Side:        model.OrderSide_Buy,
+		TimeInForce: model.TimeInForce_FOK,
+	})
+	if len(r.Trades) != 0 {
+		t.Fatalf("expect no trades but got %d", len(r.Trades))
+	}
+	if len(r.Cancellations) != 1 || r.Cancellations[0].Reason != model.CancelReason_FillOrKill || !r.Cancellations[0].Units.Equal(decimal.NewFromFloat(2)) {
+		t.Fatalf("wrong cancel output: %+v", r.Cancellations)
+	}
+	if units := engine.GetTotalSellUnitsToPrice(decimal.NewFromFloat(110)); !units.Equal(decimal.NewFromFloat(2)) {
+		t.Fatalf("expect book to be untouched, but got %s units", units)
+	}
+
+	r = engine.ProcessLimitOrder(&model.OrderLimit{
+		ID:          "4",
+		Units:       decimal.NewFromFloat(2),
+		Price:       decimal.NewFromFloat(110),
+		Side:        model.OrderSide_Buy,
+		TimeInForce: model.TimeInForce_FOK,
+	})
+	if len(r.Trades) != 2 || len(r.Cancellations) != 0 {
+		t.Fatalf("expect 2 trades and no cancels but got %+v", r)
+	}
+}
+
+func TestFillOrKillCountsHiddenUnits(t *testing.T) {
+	engine := me.NewMatchingEngine()
+	engine.ProcessLimitOrder(&model.OrderLimit{
+		ID:           "1",
+		Units:        decimal.NewFromFloat(10),
+		Price:        decimal.NewFromFloat(100),
+		Side:         model.OrderSide_Sell,
+		DisplayUnits: decimal.NewFromFloat(1),
+	})
+
+	r := engine.ProcessLimitOrder(&model.OrderLimit{
+		ID:          "2",
+		Units:       decimal.NewFromFloat(5),
+		Price:       decimal.NewFromFloat(100),
+		Side:        model.OrderSide_Buy,
+		TimeInForce: model.TimeInForce_FOK,
+	})
+	if len(r.Cancellations) != 0 {
+		t.Fatalf("expect no cancels but got %+v", r.Cancellations)
+	}
+	filled := decimal.Zero
+	for _, tr := range r.Trades {
+		filled = filled.Add(tr.Units)
+	}
+	if !filled.Equal(decimal.NewFromFloat(5)) {
+		t.Fatalf("expect 5 units filled from the iceberg but got %s", filled)
+	}
+}
+
+func TestUnknownTimeInForceRejected(t *testing.T) {
+	engine := me.NewMatchingEngine()
+
+	r := engine.ProcessLimitOrder(&model.OrderLimit{
+		ID:          "1",
+		Units:       decimal.NewFromFloat(1),
+		Price:       decimal.NewFromFloat(100),
+		Side:        model.OrderSide_Buy,
+		TimeInForce: "DAY",
+	})
+	if len(r.Rejections) != 1 || r.Rejections[0].Reason != model.RejectReason_InvalidTimeInForce {
+		t.Fatalf("wrong rejection output: %+v", r.Rejections)
+	}
+	if p := engine.GetHighestBuyPrice(); !p.IsZero() {
+		t.Fatalf("expect rejected order not to rest, but highest buy is %s", p)
+	}
+}
+
+func TestPostOnlyOrderRejected(t *testing.T) {
+	engine := me.NewMatchingEngine()
+
+	engine.ProcessLimitOrder(&model.OrderLimit{
+		ID:    "1",
+		Units: decimal.NewFromFloat(1),
+		Price: decimal.NewFromFloat(100),
+		Side:  model.OrderSide_Sell,
+	})
+
+	r := engine.ProcessLimitOrder(&model.OrderLimit{
+		ID:       "2",
+		Units:    decimal.NewFromFloat(1),
+		Price:    decimal.NewFromFloat(100),
+		Side:     model.OrderSide_Buy,
+		PostOnly: true,
+	})
+	if len(r.Trades) != 0 {
+		t.Fatalf("expect no trades but got %d", len(r.Trades))
+	}
+	if len(r.Rejections) != 1 || r.Rejections[0].Reason != model.RejectReason_PostOnlyWouldCross {
+		t.Fatalf("wrong rejection output: %+v", r.Rejections)
+	}
+	if p := engine.GetHighestBuyPrice(); !p.IsZero() {
+		t.Fatalf("expect rejected order not to rest, but highest buy is %s", p)
+	}
+}
+
+func TestPostOnlyOrderSlid(t *testing.T) {
+	engine := me.NewMatchingEngine(me.WithTickSize(decimal.NewFromFloat(0.5)))
+
+	engine.ProcessLimitOrder(&model.OrderLimit{
+		ID:    "1",
+		Units: decimal.NewFromFloat(1),
+		Price: decimal.NewFromFloat(100),
+		Side:  model.OrderSide_Buy,
+	})
+
+	r := engine.ProcessLimitOrder(&model.OrderLimit{
+		ID:           "2",
+		Units:        decimal.NewFromFloat(1),
+		Price:        decimal.NewFromFloat(99),
+		Side:         model.OrderSide_Sell,
+		PostOnly:     true,
+		PostOnlyMode: model.PostOnlyMode_Slide,
+	})
+	if len(r.Trades) != 0 || len(r.Rejections) != 0 {
+		t.Fatalf("expect order to rest, but got %+v", r)
+	}
+	if p := engine.GetLowestSellPrice(); !p.Equal(decimal.NewFromFloat(100.5)) {
+		t.Fatalf("expect lowest sell to be 100.5, but got %s", p)
+	}
+}
+
+func TestOrderExceedingPrecisionRejected(t *testing.T) {
+	engine := me.NewMatchingEngine(me.WithScales(orderbook.Scales{PriceScale: 2, UnitsScale: 3}))
+
+	r := engine.ProcessLimitOrder(&model.OrderLimit{
+		ID:    "1",
+		Units: decimal.NewFromFloat(1),
+		Price: decimal.NewFromFloat(100.005),
+		Side:  model.OrderSide_Buy,
+	})
+	if len(r.Rejections) != 1 || r.Rejections[0].Reason != model.RejectReason_PrecisionExceeded {
+		t.Fatalf("wrong rejection output: %+v", r.Rejections)
+	}
+	r = engine.ProcessMarketOrder(&model.OrderMarket{
+		ID:    "2",
+		Units: decimal.NewFromFloat(0.0001),
+		Side:  model.OrderSide_Sell,
+	})
+	if len(r.Rejections) != 1 || r.Rejections[0].Reason != model.RejectReason_PrecisionExceeded {
+		t.Fatalf("wrong rejection output: %+v", r.Rejections)
+	}
+
+	engine.ProcessLimitOrder(&model.OrderLimit{
+		ID:    "3",
+		Units: decimal.NewFromFloat(1.234),
+		Price: decimal.NewFromFloat(100.01),
+		Side:  model.OrderSide_Buy,
+	})
+	if p := engine.GetHighestBuyPrice(); !p.Equal(decimal.NewFromFloat(100.01)) {
+		t.Fatalf("expect highest buy to be 100.01, but got %s", p)
+	}
+	if _, _, err := engine.AmendOrder(&model.OrderAmend{OrderID: "3", Units: decimal.NewFromFloat(1.2345)}); err != me.ErrPrecisionExceeded {
+		t.Fatalf("expect precision error but got %v", err)
+	}
+
+	r = engine.ProcessMarketOrder(&model.OrderMarket{
+		ID:    "4",
+		Units: decimal.NewFromFloat(0.5),
+		Side:  model.OrderSide_Sell,
+	})
+	if len(r.Trades) != 1 || !r.Trades[0].Units.Equal(decimal.NewFromFloat(0.5)) || !r.Trades[0].Price.Equal(decimal.NewFromFloat(100.01)) {
+		t.Fatalf("wrong trade output: %+v", r.Trades)
+	}
+	if u := engine.GetTotalBuyUnitsFromPrice(decimal.NewFromFloat(100)); !u.Equal(decimal.NewFromFloat(0.734)) {
+		t.Fatalf("expect 0.734 units left but got %s", u)
+	}
+}
+
+func TestProRataMatching(t *testing.T) {
+	engine := me.NewMatchingEngine(me.WithAllocator(orderbook.ProRataAllocator{}))
+	for i, units := range []float64{1, 3} {
+		engine.ProcessLimitOrder(&model.OrderLimit{
+			ID:    fmt.Sprint(i + 1),
+			Units: decimal.NewFromFloat(units),
+			Price: decimal.NewFromFloat(100),
+			Side:  model.OrderSide_Sell,
+		})
+	}
+
+	r := engine.ProcessMarketOrder(&model.OrderMarket{
+		ID:    "3",
+		Units: decimal.NewFromFloat(2),
+		Side:  model.OrderSide_Buy,
+	})
+	if len(r.Trades) != 2 {
+		t.Fatalf("expect 2 trades but got %d", len(r.Trades))
+	}
+	if !r.Trades[0].Units.Equal(decimal.NewFromFloat(0.5)) || !r.Trades[1].Units.Equal(decimal.NewFromFloat(1.5)) {
+		t.Fatalf("expect trades of 0.5 and 1.5 but got %s and %s", r.Trades[0].Units, r.Trades[1].Units)
+	}
+}
+
 func BenchmarkProcessLimitOrders(b *testing.B) {
 	b.StopTimer()
 	engine := me.NewMatchingEngine()
@@ -593,3 +882,46 @@ func BenchmarkProcessOneMarketOrderProducingTrades(b *testing.B) {
 	b.StartTimer()
 	engine.ProcessMarketOrder(order)
 }
+
+// randomLimitOrders returns n buy orders with prices and units that fit the
+// default scales, which the engine does not reject for precision.
+func randomLimitOrders(n int) []*model.OrderLimit {
+	orders := make([]*model.OrderLimit, 0, n)
+	for i := 0; i < n; i++ {
+		orders = append(orders, &model.OrderLimit{
+			ID:    fmt.Sprintf("%d", i+1),
+			Units: decimal.NewFromFloat((rand.Float64() + float64(rand.Intn(2))) * 10).Round(4),
+			Price: decimal.NewFromFloat((rand.Float64() + float64(rand.Intn(2))) * 100).Round(2),
+			Side:  model.OrderSide_Buy,
+		})
+	}
+	return orders
+}
+
+func BenchmarkProcessLimitOrdersWithinScales(b *testing.B) {
+	b.StopTimer()
+	engine := me.NewMatchingEngine()
+	orders := randomLimitOrders(b.N)
+	b.StartTimer()
+	for _, order := range orders {
+		engine.ProcessLimitOrder(order)
+	}
+}
+
+func BenchmarkProcessOneMarketOrderWithinScales(b *testing.B) {
+	b.StopTimer()
+	engine := me.NewMatchingEngine()
+	var n int = 1e5
+	for _, order := range randomLimitOrders(n) {
+		engine.ProcessLimitOrder(order)
+	}
+
+	order := &model.OrderMarket{
+		ID:    fmt.Sprintf("%d", n+1),
+		Units: decimal.NewFromFloat(100),
+		Side:  model.OrderSide_Sell,
+	}
+
+	b.StartTimer()
+	engine.ProcessMarketOrder(order)
+}
@@ -0,0 +1,36 @@
+package matchingenginecore
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dylantkx/matching-engine-core/model"
+)
+
+var (
+	ErrInvalidAmend      = errors.New("amended units and price must not be negative")
+	ErrSymbolExists      = errors.New("symbol already exists")
+	ErrSymbolNotFound    = errors.New("symbol not found")
+	ErrSymbolHalted      = errors.New("symbol is halted")
+	ErrSymbolClosed      = errors.New("symbol is closed")
+	ErrInvalidTransition = errors.New("session state transition not allowed")
+	ErrEmptyCommand      = errors.New("command has nothing to process")
+	ErrJournalSequence   = errors.New("journal entries out of sequence")
+	ErrPrecisionExceeded = errors.New("price or units exceed the precision of the instrument")
+	ErrOrderRejected     = errors.New("order rejected")
+)
+
+// OrderRejectedError is returned when a change to an order breaks the
+// instrument rules. It matches ErrOrderRejected with errors.Is.
+type OrderRejectedError struct {
+	OrderID string
+	Reason  model.RejectReason
+}
+
+func (e *OrderRejectedError) Error() string {
+	return fmt.Sprintf("order %s rejected: %s", e.OrderID, e.Reason)
+}
+
+func (e *OrderRejectedError) Is(target error) bool {
+	return target == ErrOrderRejected
+}
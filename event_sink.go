@@ -0,0 +1,172 @@
+package matchingenginecore
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+
+	"github.com/dylantkx/matching-engine-core/model"
+)
+
+// EventSink receives the events of an engine. Publish is called with the
+// engine locked, once the command that produced the events is processed, so
+// a sink that blocks holds up the engine.
+type EventSink interface {
+	Publish(event model.Event)
+}
+
+// publish queues an event for the sinks of the engine.
+func (me *MatchingEngine) publish(e model.Event) {
+	if len(me.sinks) == 0 {
+		return
+	}
+	e.Symbol = me.symbol
+	me.events = append(me.events, e)
+}
+
+func (me *MatchingEngine) flushEvents() {
+	for _, e := range me.events {
+		for _, sink := range me.sinks {
+			sink.Publish(e)
+		}
+	}
+	me.events = nil
+}
+
+// ChannelSink sends every event to a channel, waiting for the receiver when
+// the channel is full.
+type ChannelSink struct {
+	ch chan<- model.Event
+}
+
+func NewChannelSink(ch chan<- model.Event) *ChannelSink {
+	return &ChannelSink{ch: ch}
+}
+
+func (s *ChannelSink) Publish(event model.Event) {
+	s.ch <- event
+}
+
+// RingBufferSink keeps up to a fixed number of events for a consumer to read.
+// When it is full, the policy decides whether the oldest event is dropped or
+// the engine waits for the consumer.
+type RingBufferSink struct {
+	events  []model.Event
+	head    int
+	size    int
+	policy  model.OverflowPolicy
+	dropped uint64
+	closed  bool
+	mu      sync.Mutex
+	cond    *sync.Cond
+}
+
+func NewRingBufferSink(capacity int, policy model.OverflowPolicy) *RingBufferSink {
+	if capacity < 1 {
+		capacity = 1
+	}
+	s := &RingBufferSink{
+		events: make([]model.Event, capacity),
+		policy: policy,
+	}
+	s.cond = sync.NewCond(&s.mu)
+	return s
+}
+
+func (s *RingBufferSink) Publish(event model.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for s.size == len(s.events) && s.policy == model.OverflowPolicy_Block && !s.closed {
+		s.cond.Wait()
+	}
+	if s.closed {
+		return
+	}
+	if s.size == len(s.events) {
+		s.head = (s.head + 1) % len(s.events)
+		s.size--
+		s.dropped++
+	}
+	s.events[(s.head+s.size)%len(s.events)] = event
+	s.size++
+	s.cond.Broadcast()
+}
+
+// Next waits for the oldest event and removes it. It returns false once the
+// sink is closed and drained.
+func (s *RingBufferSink) Next() (model.Event, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for s.size == 0 && !s.closed {
+		s.cond.Wait()
+	}
+	return s.pop()
+}
+
+// TryNext removes the oldest event without waiting.
+func (s *RingBufferSink) TryNext() (model.Event, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pop()
+}
+
+func (s *RingBufferSink) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.size
+}
+
+// Dropped returns how many events were dropped to make room for newer ones.
+func (s *RingBufferSink) Dropped() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dropped
+}
+
+// Close stops the sink from taking events and wakes up anyone waiting on it.
+// Events already buffered can still be read.
+func (s *RingBufferSink) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	s.cond.Broadcast()
+}
+
+func (s *RingBufferSink) pop() (e model.Event, ok bool) {
+	if s.size == 0 {
+		return
+	}
+	e = s.events[s.head]
+	s.events[s.head] = model.Event{}
+	s.head = (s.head + 1) % len(s.events)
+	s.size--
+	s.cond.Broadcast()
+	return e, true
+}
+
+// WriterSink writes every event to w as newline-delimited JSON. Events that
+// come after a failed write are discarded, and the error is kept for Err.
+type WriterSink struct {
+	enc *json.Encoder
+	err error
+	mu  sync.Mutex
+}
+
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{enc: json.NewEncoder(w)}
+}
+
+func (s *WriterSink) Publish(event model.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return
+	}
+	s.err = s.enc.Encode(event)
+}
+
+func (s *WriterSink) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
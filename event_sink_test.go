@@ -0,0 +1,152 @@
+package matchingenginecore_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	me "github.com/dylantkx/matching-engine-core"
+	"github.com/dylantkx/matching-engine-core/model"
+	"github.com/shopspring/decimal"
+)
+
+func TestChannelSinkReceivesEventsInOrder(t *testing.T) {
+	ch := make(chan model.Event, 100)
+	engine := me.NewMatchingEngine(me.WithSymbol("BTCUSD"), me.WithEventSink(me.NewChannelSink(ch)))
+
+	engine.ProcessLimitOrder(&model.OrderLimit{
+		ID:    "1",
+		Units: decimal.NewFromFloat(1),
+		Price: decimal.NewFromFloat(100),
+		Side:  model.OrderSide_Sell,
+	})
+	engine.ProcessMarketOrder(&model.OrderMarket{
+		ID:    "2",
+		Units: decimal.NewFromFloat(2),
+		Side:  model.OrderSide_Buy,
+	})
+	close(ch)
+
+	want := []model.EventType{
+		model.EventType_Report,
+		model.EventType_DepthUpdate,
+		model.EventType_Report,
+		model.EventType_DepthUpdate,
+		model.EventType_Trade,
+		model.EventType_Report,
+		model.EventType_Report,
+		model.EventType_Cancellation,
+		model.EventType_Report,
+	}
+	var got []model.EventType
+	for e := range ch {
+		if e.Symbol != "BTCUSD" {
+			t.Fatalf("expect symbol BTCUSD, but got %s", e.Symbol)
+		}
+		got = append(got, e.Type)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expect events %v, but got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expect events %v, but got %v", want, got)
+		}
+	}
+}
+
+func TestCancelOrderPublishesReport(t *testing.T) {
+	ch := make(chan model.Event, 100)
+	engine := me.NewMatchingEngine(me.WithEventSink(me.NewChannelSink(ch)))
+	engine.ProcessLimitOrder(&model.OrderLimit{
+		ID:    "1",
+		Units: decimal.NewFromFloat(1),
+		Price: decimal.NewFromFloat(100),
+		Side:  model.OrderSide_Buy,
+	})
+
+	if _, err := engine.CancelOrder(model.Order{ID: "1", Price: decimal.NewFromFloat(100), Side: model.OrderSide_Buy}); err != nil {
+		t.Fatalf(err.Error())
+	}
+	close(ch)
+	var last *model.ExecutionReport
+	for e := range ch {
+		if e.Report != nil {
+			last = e.Report
+		}
+	}
+	if last == nil || last.OrderID != "1" || last.ExecType != model.ExecType_Canceled || last.OrdStatus != model.OrdStatus_Canceled {
+		t.Fatalf("wrong report output: %+v", last)
+	}
+}
+
+func TestRingBufferSinkDropsOldest(t *testing.T) {
+	s := me.NewRingBufferSink(2, model.OverflowPolicy_Drop)
+	for _, id := range []string{"1", "2", "3"} {
+		s.Publish(model.Event{Type: model.EventType_Rejection, Rejection: &model.OrderRejection{OrderID: id}})
+	}
+	if s.Dropped() != 1 || s.Len() != 2 {
+		t.Fatalf("expect 1 dropped and 2 kept, but got %d and %d", s.Dropped(), s.Len())
+	}
+	e, _ := s.TryNext()
+	if e.Rejection.OrderID != "2" {
+		t.Fatalf("expect oldest kept event to be 2, but got %s", e.Rejection.OrderID)
+	}
+}
+
+func TestRingBufferSinkBlocks(t *testing.T) {
+	s := me.NewRingBufferSink(1, model.OverflowPolicy_Block)
+	s.Publish(model.Event{Type: model.EventType_Trade})
+
+	done := make(chan struct{})
+	go func() {
+		s.Publish(model.Event{Type: model.EventType_Report})
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatalf("expect publish to wait for room")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if e, ok := s.Next(); !ok || e.Type != model.EventType_Trade {
+		t.Fatalf("expect trade event, but got %+v", e)
+	}
+	<-done
+	if e, ok := s.Next(); !ok || e.Type != model.EventType_Report {
+		t.Fatalf("expect report event, but got %+v", e)
+	}
+	s.Close()
+	if _, ok := s.Next(); ok {
+		t.Fatalf("expect closed sink to be drained")
+	}
+}
+
+func TestWriterSink(t *testing.T) {
+	var buf bytes.Buffer
+	sink := me.NewWriterSink(&buf)
+	engine := me.NewMatchingEngine(me.WithEventSink(sink))
+
+	engine.ProcessLimitOrder(&model.OrderLimit{
+		ID:    "1",
+		Units: decimal.NewFromFloat(1),
+		Price: decimal.NewFromFloat(100),
+		Side:  model.OrderSide_Sell,
+	})
+	if sink.Err() != nil {
+		t.Fatalf(sink.Err().Error())
+	}
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatalf("expect 2 lines but got %d", len(lines))
+	}
+	var e model.Event
+	if err := json.Unmarshal(lines[1], &e); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if e.Type != model.EventType_DepthUpdate || !e.DepthUpdate.Size.Equal(decimal.NewFromFloat(1)) {
+		t.Fatalf("wrong event: %s", lines[1])
+	}
+}
@@ -0,0 +1,259 @@
+package matchingenginecore
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dylantkx/matching-engine-core/model"
+)
+
+const marketQueueSize int = 1024
+
+// Command is one inbound request for an Exchange. Exactly one of its fields is
+// expected to be set, and the symbol it is routed to is taken from it.
+type Command struct {
+	Limit  *model.OrderLimit    `json:"limit,omitempty"`
+	Market *model.OrderMarket   `json:"market,omitempty"`
+	Stop   *model.OrderStop     `json:"stop,omitempty"`
+	Amend  *model.OrderAmend    `json:"amend,omitempty"`
+	Cancel *model.CancelRequest `json:"cancel,omitempty"`
+
+	// Session moves the symbol to another session state.
+	Session *model.SessionRequest `json:"session,omitempty"`
+
+	// The fields below are only issued by the MatchingEngine methods
+	// CancelOrder, ExpireOrders, StartAuction and Uncross. They carry no
+	// symbol and are not routed.
+	CancelOrder  *model.Order `json:"cancelOrder,omitempty"`
+	Expire       bool         `json:"expire,omitempty"`
+	StartAuction bool         `json:"startAuction,omitempty"`
+	Uncross      bool         `json:"uncross,omitempty"`
+}
+
+func (c *Command) Symbol() string {
+	switch {
+	case c.Limit != nil:
+		return c.Limit.Symbol
+	case c.Market != nil:
+		return c.Market.Symbol
+	case c.Stop != nil:
+		return c.Stop.Symbol
+	case c.Amend != nil:
+		return c.Amend.Symbol
+	case c.Cancel != nil:
+		return c.Cancel.Symbol
+	case c.Session != nil:
+		return c.Session.Symbol
+	}
+	return ""
+}
+
+type CommandResult struct {
+	Symbol   string               `json:"symbol"`
+	Result   model.MatchResult    `json:"result"`
+	AmendAck *model.OrderAmendAck `json:"amendAck,omitempty"`
+	Err      error                `json:"-"`
+}
+
+// Exchange runs one MatchingEngine per symbol. Every engine is driven by its
+// own goroutine, so symbols never wait on each other, while commands for the
+// same symbol are processed one at a time in the order they were submitted.
+type Exchange struct {
+	markets map[string]*market
+	mu      sync.RWMutex
+}
+
+type market struct {
+	engine *MatchingEngine
+	cmds   chan func()
+	done   chan struct{}
+	closed bool
+	mu     sync.RWMutex
+}
+
+func NewExchange() *Exchange {
+	return &Exchange{
+		markets: make(map[string]*market),
+	}
+}
+
+// CreateSymbol starts an engine for symbol. WithSymbol is applied after opts,
+// so the engine is always named after the symbol it is registered under.
+func (ex *Exchange) CreateSymbol(symbol string, opts ...Option) (*MatchingEngine, error) {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+	if _, ok := ex.markets[symbol]; ok {
+		return nil, ErrSymbolExists
+	}
+	m := &market{
+		engine: NewMatchingEngine(append(opts, WithSymbol(symbol))...),
+		cmds:   make(chan func(), marketQueueSize),
+		done:   make(chan struct{}),
+	}
+	go m.run()
+	ex.markets[symbol] = m
+	return m.engine, nil
+}
+
+// RemoveSymbol stops the engine of symbol once the commands already queued for
+// it are processed. Its resting orders are dropped with it.
+func (ex *Exchange) RemoveSymbol(symbol string) error {
+	ex.mu.Lock()
+	m, ok := ex.markets[symbol]
+	delete(ex.markets, symbol)
+	ex.mu.Unlock()
+	if !ok {
+		return ErrSymbolNotFound
+	}
+	m.close()
+	return nil
+}
+
+func (ex *Exchange) GetEngine(symbol string) (*MatchingEngine, bool) {
+	m, ok := ex.getMarket(symbol)
+	if !ok {
+		return nil, false
+	}
+	return m.engine, true
+}
+
+func (ex *Exchange) ListSymbols() []string {
+	ex.mu.RLock()
+	defer ex.mu.RUnlock()
+	symbols := make([]string, 0, len(ex.markets))
+	for s := range ex.markets {
+		symbols = append(symbols, s)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// HaltSymbol moves symbol to the halted session, where it stops accepting new
+// orders. Cancels still go through.
+func (ex *Exchange) HaltSymbol(symbol string) error {
+	_, err := ex.SetSessionState(&model.SessionRequest{Symbol: symbol, State: model.SessionState_Halted})
+	return err
+}
+
+// ResumeSymbol moves symbol back to the open session.
+func (ex *Exchange) ResumeSymbol(symbol string) error {
+	_, err := ex.SetSessionState(&model.SessionRequest{Symbol: symbol, State: model.SessionState_Open})
+	return err
+}
+
+func (ex *Exchange) IsHalted(symbol string) (bool, error) {
+	m, ok := ex.getMarket(symbol)
+	if !ok {
+		return false, ErrSymbolNotFound
+	}
+	return m.engine.GetSessionState() == model.SessionState_Halted, nil
+}
+
+// SetSessionState moves a symbol to another session state, once the commands
+// queued for it before are processed. See MatchingEngine.SetSessionState.
+func (ex *Exchange) SetSessionState(req *model.SessionRequest) (model.MatchResult, error) {
+	cr := <-ex.submit(Command{Session: req})
+	return cr.Result, cr.Err
+}
+
+func (ex *Exchange) ProcessLimitOrder(order *model.OrderLimit) (model.MatchResult, error) {
+	cr := <-ex.submit(Command{Limit: order})
+	return cr.Result, cr.Err
+}
+
+func (ex *Exchange) ProcessMarketOrder(order *model.OrderMarket) (model.MatchResult, error) {
+	cr := <-ex.submit(Command{Market: order})
+	return cr.Result, cr.Err
+}
+
+func (ex *Exchange) ProcessStopOrder(order *model.OrderStop) (model.MatchResult, error) {
+	cr := <-ex.submit(Command{Stop: order})
+	return cr.Result, cr.Err
+}
+
+func (ex *Exchange) AmendOrder(amend *model.OrderAmend) (model.MatchResult, model.OrderAmendAck, error) {
+	cr := <-ex.submit(Command{Amend: amend})
+	if cr.AmendAck == nil {
+		return cr.Result, model.OrderAmendAck{}, cr.Err
+	}
+	return cr.Result, *cr.AmendAck, cr.Err
+}
+
+func (ex *Exchange) CancelOrder(req *model.CancelRequest) (model.MatchResult, error) {
+	cr := <-ex.submit(Command{Cancel: req})
+	return cr.Result, cr.Err
+}
+
+// Submit routes every command to the engine of its symbol and returns their
+// results in the same order. Commands for different symbols run concurrently.
+func (ex *Exchange) Submit(cmds ...Command) []CommandResult {
+	pending := make([]<-chan CommandResult, 0, len(cmds))
+	for _, cmd := range cmds {
+		pending = append(pending, ex.submit(cmd))
+	}
+	results := make([]CommandResult, 0, len(cmds))
+	for _, ch := range pending {
+		results = append(results, <-ch)
+	}
+	return results
+}
+
+// Close stops the engines of every symbol.
+func (ex *Exchange) Close() {
+	ex.mu.Lock()
+	markets := ex.markets
+	ex.markets = make(map[string]*market)
+	ex.mu.Unlock()
+	for _, m := range markets {
+		m.close()
+	}
+}
+
+func (ex *Exchange) submit(cmd Command) <-chan CommandResult {
+	ch := make(chan CommandResult, 1)
+	symbol := cmd.Symbol()
+	m, ok := ex.getMarket(symbol)
+	if !ok {
+		ch <- CommandResult{Symbol: symbol, Err: ErrSymbolNotFound}
+		return ch
+	}
+	if err := m.enqueue(func() { ch <- m.engine.execute(cmd, time.Now()) }); err != nil {
+		ch <- CommandResult{Symbol: symbol, Err: err}
+	}
+	return ch
+}
+
+func (ex *Exchange) getMarket(symbol string) (*market, bool) {
+	ex.mu.RLock()
+	defer ex.mu.RUnlock()
+	m, ok := ex.markets[symbol]
+	return m, ok
+}
+
+func (m *market) run() {
+	for fn := range m.cmds {
+		fn()
+	}
+	close(m.done)
+}
+
+func (m *market) enqueue(fn func()) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.closed {
+		return ErrSymbolNotFound
+	}
+	m.cmds <- fn
+	return nil
+}
+
+func (m *market) close() {
+	m.mu.Lock()
+	if !m.closed {
+		m.closed = true
+		close(m.cmds)
+	}
+	m.mu.Unlock()
+	<-m.done
+}
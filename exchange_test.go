@@ -0,0 +1,128 @@
+package matchingenginecore_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	me "github.com/dylantkx/matching-engine-core"
+	"github.com/dylantkx/matching-engine-core/model"
+	"github.com/shopspring/decimal"
+)
+
+func TestExchangeRoutesOrdersBySymbol(t *testing.T) {
+	ex := me.NewExchange()
+	defer ex.Close()
+
+	for _, s := range []string{"ETHUSD", "BTCUSD"} {
+		if _, err := ex.CreateSymbol(s); err != nil {
+			t.Fatalf(err.Error())
+		}
+	}
+	if _, err := ex.CreateSymbol("BTCUSD"); !errors.Is(err, me.ErrSymbolExists) {
+		t.Fatalf("expect symbol exists error, but got %v", err)
+	}
+	if symbols := ex.ListSymbols(); len(symbols) != 2 || symbols[0] != "BTCUSD" {
+		t.Fatalf("wrong symbols: %v", symbols)
+	}
+
+	results := ex.Submit(
+		me.Command{Limit: &model.OrderLimit{
+			Symbol: "BTCUSD",
+			ID:     "1",
+			Units:  decimal.NewFromFloat(1),
+			Price:  decimal.NewFromFloat(100),
+			Side:   model.OrderSide_Sell,
+		}},
+		me.Command{Market: &model.OrderMarket{
+			Symbol: "ETHUSD",
+			ID:     "2",
+			Units:  decimal.NewFromFloat(1),
+			Side:   model.OrderSide_Buy,
+		}},
+		me.Command{Market: &model.OrderMarket{
+			Symbol: "BTCUSD",
+			ID:     "3",
+			Units:  decimal.NewFromFloat(1),
+			Side:   model.OrderSide_Buy,
+		}},
+		me.Command{Market: &model.OrderMarket{
+			Symbol: "XRPUSD",
+			ID:     "4",
+			Units:  decimal.NewFromFloat(1),
+			Side:   model.OrderSide_Buy,
+		}},
+	)
+	if len(results) != 4 {
+		t.Fatalf("expect 4 results but got %d", len(results))
+	}
+	if len(results[1].Result.Trades) != 0 || len(results[1].Result.Cancellations) != 1 {
+		t.Fatalf("expect ETHUSD order to be cancelled, but got %+v", results[1])
+	}
+	if len(results[2].Result.Trades) != 1 || results[2].Result.Trades[0].Symbol != "BTCUSD" {
+		t.Fatalf("expect BTCUSD order to trade, but got %+v", results[2])
+	}
+	if !errors.Is(results[3].Err, me.ErrSymbolNotFound) {
+		t.Fatalf("expect symbol not found error, but got %v", results[3].Err)
+	}
+}
+
+func TestExchangeHaltSymbol(t *testing.T) {
+	ex := me.NewExchange()
+	defer ex.Close()
+	ex.CreateSymbol("BTCUSD")
+
+	ex.ProcessLimitOrder(&model.OrderLimit{
+		Symbol: "BTCUSD",
+		ID:     "1",
+		Units:  decimal.NewFromFloat(1),
+		Price:  decimal.NewFromFloat(100),
+		Side:   model.OrderSide_Sell,
+	})
+	if err := ex.HaltSymbol("BTCUSD"); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	_, err := ex.ProcessMarketOrder(&model.OrderMarket{
+		Symbol: "BTCUSD",
+		ID:     "2",
+		Units:  decimal.NewFromFloat(1),
+		Side:   model.OrderSide_Buy,
+	})
+	if !errors.Is(err, me.ErrSymbolHalted) {
+		t.Fatalf("expect symbol halted error, but got %v", err)
+	}
+	r, err := ex.CancelOrder(&model.CancelRequest{Symbol: "BTCUSD", OrderID: "1"})
+	if err != nil || len(r.Cancellations) != 1 {
+		t.Fatalf("expect cancel to go through while halted, but got %+v, %v", r, err)
+	}
+
+	if err := ex.RemoveSymbol("BTCUSD"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if _, ok := ex.GetEngine("BTCUSD"); ok {
+		t.Fatalf("expect symbol to be removed")
+	}
+}
+
+func BenchmarkExchangeManySymbols(b *testing.B) {
+	b.StopTimer()
+	ex := me.NewExchange()
+	defer ex.Close()
+	symbols := 16
+	for i := 0; i < symbols; i++ {
+		ex.CreateSymbol(fmt.Sprintf("SYM%d", i))
+	}
+	cmds := make([]me.Command, 0, b.N)
+	for i := 0; i < b.N; i++ {
+		cmds = append(cmds, me.Command{Limit: &model.OrderLimit{
+			Symbol: fmt.Sprintf("SYM%d", i%symbols),
+			ID:     fmt.Sprintf("%d", i+1),
+			Units:  decimal.NewFromFloat(1),
+			Price:  decimal.NewFromFloat(float64(100 + i%10)),
+			Side:   model.OrderSide_Buy,
+		}})
+	}
+	b.StartTimer()
+	ex.Submit(cmds...)
+}
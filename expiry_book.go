@@ -0,0 +1,80 @@
+package matchingenginecore
+
+import (
+	"time"
+
+	"github.com/google/btree"
+)
+
+type expiryBookItem struct {
+	OrderID    string
+	ExpireTime time.Time
+	Seq        uint64
+}
+
+// expiryBook tracks when resting good-till-date orders expire. Entries are
+// not removed when an order fills, so an expired ID may no longer be resting.
+type expiryBook struct {
+	tree   *btree.BTreeG[expiryBookItem]
+	orders map[string]expiryBookItem
+	seq    uint64
+}
+
+func newExpiryBook() *expiryBook {
+	return &expiryBook{
+		tree: btree.NewG(treeDegree, func(a, b expiryBookItem) bool {
+			if !a.ExpireTime.Equal(b.ExpireTime) {
+				return a.ExpireTime.Before(b.ExpireTime)
+			}
+			return a.Seq < b.Seq
+		}),
+		orders: make(map[string]expiryBookItem),
+	}
+}
+
+// Add tracks id to expire at expireTime, in whole seconds as it is journaled.
+func (eb *expiryBook) Add(id string, expireTime time.Time) {
+	eb.Remove(id)
+	eb.seq++
+	item := expiryBookItem{
+		OrderID:    id,
+		ExpireTime: expireTime.Truncate(time.Second),
+		Seq:        eb.seq,
+	}
+	eb.tree.ReplaceOrInsert(item)
+	eb.orders[id] = item
+}
+
+func (eb *expiryBook) Remove(id string) {
+	item, ok := eb.orders[id]
+	if !ok {
+		return
+	}
+	eb.tree.Delete(item)
+	delete(eb.orders, id)
+}
+
+// IDs returns the IDs of tracked orders in the order they would expire.
+func (eb *expiryBook) IDs() (ids []string) {
+	eb.tree.Ascend(func(item expiryBookItem) bool {
+		ids = append(ids, item.OrderID)
+		return true
+	})
+	return
+}
+
+// PopExpired removes and returns the IDs of orders expiring at or before now,
+// earliest first.
+func (eb *expiryBook) PopExpired(now time.Time) (ids []string) {
+	eb.tree.Ascend(func(item expiryBookItem) bool {
+		if item.ExpireTime.After(now) {
+			return false
+		}
+		ids = append(ids, item.OrderID)
+		return true
+	})
+	for _, id := range ids {
+		eb.Remove(id)
+	}
+	return
+}
@@ -0,0 +1,74 @@
+package matchingenginecore_test
+
+import (
+	"testing"
+	"time"
+
+	me "github.com/dylantkx/matching-engine-core"
+	"github.com/dylantkx/matching-engine-core/model"
+	"github.com/shopspring/decimal"
+)
+
+func TestExpireGoodTillDateOrders(t *testing.T) {
+	engine := me.NewMatchingEngine()
+	now := time.Now()
+
+	orders := []model.OrderLimit{
+		{
+			ID:          "1",
+			Units:       decimal.NewFromFloat(1),
+			Price:       decimal.NewFromFloat(100),
+			Side:        model.OrderSide_Buy,
+			TimeInForce: model.TimeInForce_GTD,
+			ExpireTime:  model.Timestamp{Time: now.Add(time.Minute)},
+		},
+		{
+			ID:          "2",
+			Units:       decimal.NewFromFloat(1),
+			Price:       decimal.NewFromFloat(90),
+			Side:        model.OrderSide_Buy,
+			TimeInForce: model.TimeInForce_GTD,
+			ExpireTime:  model.Timestamp{Time: now.Add(time.Hour)},
+		},
+	}
+	for _, ord := range orders {
+		engine.ProcessLimitOrder(&ord)
+	}
+
+	cancels := engine.ExpireOrders(now.Add(2 * time.Minute)).Cancellations
+	if len(cancels) != 1 {
+		t.Fatalf("expect 1 cancel but got %d", len(cancels))
+	}
+	if cancels[0].OrderID != "1" || cancels[0].Reason != model.CancelReason_Expired {
+		t.Fatalf("wrong cancel output: %+v", cancels[0])
+	}
+	if p := engine.GetHighestBuyPrice(); !p.Equal(decimal.NewFromFloat(90)) {
+		t.Fatalf("expect highest buy to be 90, but got %s", p)
+	}
+
+	if _, err := engine.CancelOrderByID("2"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if cancels := engine.ExpireOrders(now.Add(2 * time.Hour)).Cancellations; len(cancels) != 0 {
+		t.Fatalf("expect no cancels but got %+v", cancels)
+	}
+}
+
+func TestRejectAlreadyExpiredOrder(t *testing.T) {
+	engine := me.NewMatchingEngine()
+
+	r := engine.ProcessLimitOrder(&model.OrderLimit{
+		ID:          "1",
+		Units:       decimal.NewFromFloat(1),
+		Price:       decimal.NewFromFloat(100),
+		Side:        model.OrderSide_Buy,
+		TimeInForce: model.TimeInForce_GTD,
+		ExpireTime:  model.Timestamp{Time: time.Now().Add(-time.Second)},
+	})
+	if len(r.Cancellations) != 1 || r.Cancellations[0].Reason != model.CancelReason_Expired {
+		t.Fatalf("wrong cancel output: %+v", r.Cancellations)
+	}
+	if p := engine.GetHighestBuyPrice(); !p.IsZero() {
+		t.Fatalf("expect expired order not to rest, but highest buy is %s", p)
+	}
+}
@@ -0,0 +1,157 @@
+package matchingenginecore
+
+import (
+	"time"
+
+	"github.com/dylantkx/matching-engine-core/model"
+	"github.com/shopspring/decimal"
+)
+
+// feeVolumeDays is how many days of traded volume pick the fee tier, the
+// current day included. Days start at midnight UTC.
+const feeVolumeDays = 30
+
+// feeLedger prices the fees of trades and keeps what they earned. The tier of
+// an owner is picked by the quote volume the owner traded on the instrument
+// within the last feeVolumeDays days, before the trade being charged.
+type feeLedger struct {
+	schedule model.FeeSchedule
+	volumes  map[string]*dailyVolumes
+	revenue  model.FeeRevenue
+}
+
+func newFeeLedger(schedule model.FeeSchedule) *feeLedger {
+	return &feeLedger{
+		schedule: schedule,
+		volumes:  make(map[string]*dailyVolumes),
+	}
+}
+
+func (fl *feeLedger) volume(ownerID string, now time.Time) decimal.Decimal {
+	dv := fl.volumes[ownerID]
+	if dv == nil {
+		return decimal.Zero
+	}
+	dv.evict(now)
+	return dv.total
+}
+
+func (fl *feeLedger) addVolume(ownerID string, tr model.Trade) {
+	dv := fl.volumes[ownerID]
+	if dv == nil {
+		dv = &dailyVolumes{}
+		fl.volumes[ownerID] = dv
+	}
+	dv.add(tr.EventTime.Time, tr.Units.Mul(tr.Price))
+}
+
+type dayVolume struct {
+	day    int64
+	volume decimal.Decimal
+}
+
+// dailyVolumes adds up the quote volume of one owner per day over the last
+// feeVolumeDays days, so it holds at most that many entries however much the
+// owner trades.
+type dailyVolumes struct {
+	days  []dayVolume
+	total decimal.Decimal
+}
+
+// add books volume on the day of at. Volume stamped before the latest day is
+// booked on that day, as the days are kept in order.
+func (dv *dailyVolumes) add(at time.Time, volume decimal.Decimal) {
+	dv.evict(at)
+	day := unixDay(at)
+	if n := len(dv.days); n > 0 && dv.days[n-1].day >= day {
+		dv.days[n-1].volume = dv.days[n-1].volume.Add(volume)
+	} else {
+		dv.days = append(dv.days, dayVolume{day: day, volume: volume})
+	}
+	dv.total = dv.total.Add(volume)
+}
+
+// evict drops the days that are no longer inside the window ending on the day
+// of now.
+func (dv *dailyVolumes) evict(now time.Time) {
+	first := unixDay(now) - feeVolumeDays + 1
+	for len(dv.days) > 0 && dv.days[0].day < first {
+		dv.total = dv.total.Sub(dv.days[0].volume)
+		dv.days = dv.days[1:]
+	}
+}
+
+// unixDay numbers the UTC day of t, counting from the Unix epoch.
+func unixDay(t time.Time) int64 {
+	day := t.Unix() / 86400
+	if t.Unix() < 0 && t.Unix()%86400 != 0 {
+		day--
+	}
+	return day
+}
+
+func (fl *feeLedger) rate(ownerID string, isMaker bool, now time.Time) decimal.Decimal {
+	tier := fl.schedule.Tier(ownerID, fl.volume(ownerID, now))
+	if isMaker {
+		return tier.MakerRate
+	}
+	return tier.TakerRate
+}
+
+// chargeFees sets the fees of tr, which both sides pay in the asset they
+// receive, and books them.
+func (me *MatchingEngine) chargeFees(tr *model.Trade) {
+	if me.fees == nil {
+		return
+	}
+	var buyerID, sellerID string
+	if s := me.states[tr.BuyOrderID]; s != nil {
+		buyerID = s.OwnerID
+	}
+	if s := me.states[tr.SellOrderID]; s != nil {
+		sellerID = s.OwnerID
+	}
+	now := tr.EventTime.Time
+	buyRate := me.fees.rate(buyerID, tr.IsBuyerMaker, now)
+	sellRate := me.fees.rate(sellerID, !tr.IsBuyerMaker, now)
+	tr.Fees = &model.TradeFees{
+		Buyer: model.Fee{
+			Amount: me.fees.schedule.Round(tr.Units.Mul(buyRate)),
+			Asset:  me.baseAsset,
+			Rate:   buyRate,
+		},
+		Seller: model.Fee{
+			Amount: me.fees.schedule.Round(tr.Units.Mul(tr.Price).Mul(sellRate)),
+			Asset:  me.quoteAsset,
+			Rate:   sellRate,
+		},
+	}
+	me.fees.revenue.Base = me.fees.revenue.Base.Add(tr.Fees.Buyer.Amount)
+	me.fees.revenue.Quote = me.fees.revenue.Quote.Add(tr.Fees.Seller.Amount)
+	me.fees.addVolume(buyerID, *tr)
+	if sellerID != buyerID {
+		me.fees.addVolume(sellerID, *tr)
+	}
+}
+
+// GetFeeRevenue returns what the instrument earned in fees, net of rebates.
+func (me *MatchingEngine) GetFeeRevenue() model.FeeRevenue {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	if me.fees == nil {
+		return model.FeeRevenue{}
+	}
+	return me.fees.revenue
+}
+
+// GetTradedVolume returns the quote volume an owner traded over the last 30
+// days, the current UTC day included, which picks the fee tier of the owner. It is only tracked with
+// WithFeeSchedule.
+func (me *MatchingEngine) GetTradedVolume(ownerID string) decimal.Decimal {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	if me.fees == nil {
+		return decimal.Zero
+	}
+	return me.fees.volume(ownerID, time.Now())
+}
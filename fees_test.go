@@ -0,0 +1,132 @@
+package matchingenginecore_test
+
+import (
+	"testing"
+	"time"
+
+	me "github.com/dylantkx/matching-engine-core"
+	"github.com/dylantkx/matching-engine-core/account"
+	"github.com/dylantkx/matching-engine-core/model"
+	"github.com/shopspring/decimal"
+)
+
+var testFeeSchedule = model.FeeSchedule{
+	Tiers: []model.FeeTier{
+		{MakerRate: decimal.NewFromFloat(-0.0001), TakerRate: decimal.NewFromFloat(0.002)},
+		{MinVolume: decimal.NewFromInt(1000), TakerRate: decimal.NewFromFloat(0.001)},
+	},
+	Places: 4,
+}
+
+// crossOrders rests a sell of bob and buys it from alice.
+func crossOrders(engine *me.MatchingEngine, id string, units int64) model.MatchResult {
+	engine.ProcessLimitOrder(&model.OrderLimit{
+		ID:      id + "s",
+		OwnerID: "bob",
+		Units:   decimal.NewFromInt(units),
+		Price:   decimal.NewFromInt(100),
+		Side:    model.OrderSide_Sell,
+	})
+	return engine.ProcessMarketOrder(&model.OrderMarket{
+		ID:      id + "b",
+		OwnerID: "alice",
+		Units:   decimal.NewFromInt(units),
+		Side:    model.OrderSide_Buy,
+	})
+}
+
+func TestTradeFees(t *testing.T) {
+	engine := me.NewMatchingEngine(me.WithFeeSchedule(testFeeSchedule))
+
+	r := crossOrders(engine, "1", 2)
+	if len(r.Trades) != 1 || r.Trades[0].Fees == nil {
+		t.Fatalf("expect a trade with fees but got %+v", r.Trades)
+	}
+	fees := r.Trades[0].Fees
+	if !fees.Buyer.Amount.Equal(decimal.NewFromFloat(0.004)) {
+		t.Fatalf("expect taker to pay 0.004 but got %s", fees.Buyer.Amount)
+	}
+	if !fees.Seller.Amount.Equal(decimal.NewFromFloat(-0.02)) {
+		t.Fatalf("expect maker to get a rebate of 0.02 but got %s", fees.Seller.Amount.Neg())
+	}
+	for _, rep := range r.Reports {
+		if rep.ExecType == model.ExecType_Trade && rep.Fee == nil {
+			t.Fatalf("expect trade report of order %s to carry its fee", rep.OrderID)
+		}
+	}
+
+	// 200 traded before, which takes alice to the next tier after this trade
+	crossOrders(engine, "2", 8)
+	r = crossOrders(engine, "3", 1)
+	if !r.Trades[0].Fees.Buyer.Rate.Equal(decimal.NewFromFloat(0.001)) {
+		t.Fatalf("expect taker rate of the second tier but got %s", r.Trades[0].Fees.Buyer.Rate)
+	}
+	if v := engine.GetTradedVolume("alice"); !v.Equal(decimal.NewFromInt(1100)) {
+		t.Fatalf("expect traded volume of 1100 but got %s", v)
+	}
+
+	revenue := engine.GetFeeRevenue()
+	if !revenue.Base.Equal(decimal.NewFromFloat(0.021)) || !revenue.Quote.Equal(decimal.NewFromFloat(-0.1)) {
+		t.Fatalf("expect revenue of 0.021 base and -0.1 quote but got %+v", revenue)
+	}
+}
+
+func TestTradeFeesSettled(t *testing.T) {
+	acc := account.NewAccounts()
+	acc.Deposit("alice", "USD", decimal.NewFromInt(1000))
+	acc.Deposit("bob", "BTC", decimal.NewFromInt(5))
+	engine := me.NewMatchingEngine(
+		me.WithAccounts(acc, "BTC", "USD"),
+		me.WithFeeSchedule(testFeeSchedule),
+	)
+
+	r := crossOrders(engine, "1", 2)
+	if len(r.Trades) != 1 || r.Trades[0].Fees.Buyer.Asset != "BTC" || r.Trades[0].Fees.Seller.Asset != "USD" {
+		t.Fatalf("expect fees in the received assets but got %+v", r.Trades)
+	}
+	if b := acc.GetBalance("alice", "BTC"); !b.Available.Equal(decimal.NewFromFloat(1.996)) {
+		t.Fatalf("expect alice to receive 1.996 BTC but got %s", b.Available)
+	}
+	if b := acc.GetBalance("bob", "USD"); !b.Available.Equal(decimal.NewFromFloat(200.02)) {
+		t.Fatalf("expect bob to receive 200.02 USD but got %s", b.Available)
+	}
+}
+
+func TestTradedVolumeLeavesWindowByDay(t *testing.T) {
+	now := time.Now()
+	var entries []me.JournalEntry
+	add := func(at time.Time, cmd me.Command) {
+		entries = append(entries, me.JournalEntry{
+			Seq:     uint64(len(entries) + 1),
+			Time:    at,
+			Command: cmd,
+		})
+	}
+	cross := func(at time.Time, id string, units int64) {
+		add(at, me.Command{Limit: &model.OrderLimit{
+			ID:      id + "s",
+			OwnerID: "bob",
+			Units:   decimal.NewFromInt(units),
+			Price:   decimal.NewFromInt(100),
+			Side:    model.OrderSide_Sell,
+		}})
+		add(at, me.Command{Market: &model.OrderMarket{
+			ID:      id + "b",
+			OwnerID: "alice",
+			Units:   decimal.NewFromInt(units),
+			Side:    model.OrderSide_Buy,
+		}})
+	}
+	cross(now.Add(-31*24*time.Hour), "1", 8)
+	cross(now.Add(-20*24*time.Hour), "2", 2)
+	cross(now.Add(-20*24*time.Hour), "3", 3)
+	cross(now, "4", 1)
+
+	engine, err := me.Replay(entries, me.WithFeeSchedule(testFeeSchedule))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if v := engine.GetTradedVolume("alice"); !v.Equal(decimal.NewFromInt(600)) {
+		t.Fatalf("expect traded volume of 600 but got %s", v)
+	}
+}
@@ -0,0 +1,109 @@
+package matchingenginecore
+
+import (
+	"github.com/dylantkx/matching-engine-core/account"
+	"github.com/dylantkx/matching-engine-core/model"
+	"github.com/shopspring/decimal"
+)
+
+// lockedFor returns the funds an order needs to rest units at price: quote
+// for a buy and base for a sell.
+func lockedFor(side model.OrderSide, units, price decimal.Decimal) decimal.Decimal {
+	if side == model.OrderSide_Buy {
+		return units.Mul(price)
+	}
+	return units
+}
+
+// fundingAsset returns the asset an order of side pays with.
+func (me *MatchingEngine) fundingAsset(side model.OrderSide) string {
+	if side == model.OrderSide_Buy {
+		return me.quoteAsset
+	}
+	return me.baseAsset
+}
+
+// holdFunds locks what the order of s needs on top of what it already holds
+// to hold amount, and tells if its owner could afford it.
+func (me *MatchingEngine) holdFunds(s *OrderState, amount decimal.Decimal) bool {
+	if me.accounts == nil {
+		return true
+	}
+	extra := amount.Sub(s.Locked)
+	if !extra.IsPositive() {
+		return true
+	}
+	if err := me.accounts.Lock(s.OwnerID, me.fundingAsset(s.Side), extra); err != nil {
+		return false
+	}
+	s.Locked = amount
+	return true
+}
+
+// holdMarketFunds locks the funds of a market order. A buy is held for its
+// quote budget, or else for the cost of sweeping the book for its units,
+// which is the most it can pay.
+func (me *MatchingEngine) holdMarketFunds(s *OrderState, order *model.OrderMarket, limit *decimal.Decimal) bool {
+	if me.accounts == nil {
+		return true
+	}
+	if order.Side == model.OrderSide_Sell {
+		return me.holdFunds(s, order.Units)
+	}
+	if order.QuoteAmount.IsPositive() {
+		return me.holdFunds(s, order.QuoteAmount)
+	}
+	return me.holdFunds(s, me.book.GetSellSideCost(order.Units, me.clearOptions(order.OwnerID, limit)))
+}
+
+// releaseFunds unlocks what the order of s holds beyond keep.
+func (me *MatchingEngine) releaseFunds(s *OrderState, keep decimal.Decimal) {
+	if me.accounts == nil {
+		return
+	}
+	extra := s.Locked.Sub(keep)
+	if !extra.IsPositive() {
+		return
+	}
+	me.accounts.Unlock(s.OwnerID, me.fundingAsset(s.Side), extra)
+	s.Locked = keep
+}
+
+// releaseExcess unlocks what the order of s holds beyond what its leaves
+// need, such as the savings of a buy filled below its price. A market buy has
+// no price to go by and keeps its funds until it is done.
+func (me *MatchingEngine) releaseExcess(s *OrderState) {
+	if s.Side == model.OrderSide_Buy && !s.Price.IsPositive() {
+		return
+	}
+	me.releaseFunds(s, lockedFor(s.Side, s.LeavesUnits, s.Price))
+}
+
+// settleTrade pays both sides of tr out of the funds held by their orders,
+// less their fees.
+func (me *MatchingEngine) settleTrade(tr model.Trade) {
+	if me.accounts == nil {
+		return
+	}
+	buyer, seller := me.states[tr.BuyOrderID], me.states[tr.SellOrderID]
+	if buyer == nil || seller == nil {
+		return
+	}
+	quote := tr.Units.Mul(tr.Price)
+	var buyerFee, sellerFee decimal.Decimal
+	if tr.Fees != nil {
+		buyerFee, sellerFee = tr.Fees.Buyer.Amount, tr.Fees.Seller.Amount
+	}
+	me.accounts.Settle(account.Settlement{
+		BuyerID:     buyer.OwnerID,
+		SellerID:    seller.OwnerID,
+		Base:        me.baseAsset,
+		Quote:       me.quoteAsset,
+		Units:       tr.Units,
+		QuoteAmount: quote,
+		BuyerFee:    buyerFee,
+		SellerFee:   sellerFee,
+	})
+	buyer.Locked = buyer.Locked.Sub(quote)
+	seller.Locked = seller.Locked.Sub(tr.Units)
+}
@@ -0,0 +1,138 @@
+package matchingenginecore_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	me "github.com/dylantkx/matching-engine-core"
+	"github.com/dylantkx/matching-engine-core/account"
+	"github.com/dylantkx/matching-engine-core/model"
+	"github.com/shopspring/decimal"
+)
+
+// newFundedEngine returns an engine trading BTC for USD where alice holds
+// 1000 USD and bob holds 5 BTC.
+func newFundedEngine() (*me.MatchingEngine, *account.Accounts) {
+	acc := account.NewAccounts()
+	acc.Deposit("alice", "USD", decimal.NewFromInt(1000))
+	acc.Deposit("bob", "BTC", decimal.NewFromInt(5))
+	return me.NewMatchingEngine(me.WithAccounts(acc, "BTC", "USD")), acc
+}
+
+func expectBalance(t *testing.T, acc *account.Accounts, owner, asset string, available, locked int64) {
+	t.Helper()
+	b := acc.GetBalance(owner, asset)
+	if !b.Available.Equal(decimal.NewFromInt(available)) || !b.Locked.Equal(decimal.NewFromInt(locked)) {
+		t.Fatalf("expect %s to hold %d %s available and %d locked but got %s and %s",
+			owner, available, asset, locked, b.Available, b.Locked)
+	}
+}
+
+func TestFundsLockedAndReleased(t *testing.T) {
+	engine, acc := newFundedEngine()
+
+	engine.ProcessLimitOrder(&model.OrderLimit{
+		ID:      "1",
+		OwnerID: "alice",
+		Units:   decimal.NewFromInt(3),
+		Price:   decimal.NewFromInt(100),
+		Side:    model.OrderSide_Buy,
+	})
+	expectBalance(t, acc, "alice", "USD", 700, 300)
+
+	_, _, err := engine.AmendOrder(&model.OrderAmend{OrderID: "1", Units: decimal.NewFromInt(2)})
+	if err != nil {
+		t.Fatalf("expect amend to succeed but got %v", err)
+	}
+	expectBalance(t, acc, "alice", "USD", 800, 200)
+
+	_, _, err = engine.AmendOrder(&model.OrderAmend{OrderID: "1", Units: decimal.NewFromInt(20)})
+	if !errors.Is(err, me.ErrOrderRejected) {
+		t.Fatalf("expect amend beyond funds to be rejected but got %v", err)
+	}
+	expectBalance(t, acc, "alice", "USD", 800, 200)
+
+	if _, err := engine.CancelOrderByID("1"); err != nil {
+		t.Fatalf("expect cancel to succeed but got %v", err)
+	}
+	expectBalance(t, acc, "alice", "USD", 1000, 0)
+}
+
+func TestFundsSettledOnTrade(t *testing.T) {
+	engine, acc := newFundedEngine()
+
+	engine.ProcessLimitOrder(&model.OrderLimit{
+		ID:      "1",
+		OwnerID: "bob",
+		Units:   decimal.NewFromInt(2),
+		Price:   decimal.NewFromInt(100),
+		Side:    model.OrderSide_Sell,
+	})
+	expectBalance(t, acc, "bob", "BTC", 3, 2)
+
+	r := engine.ProcessLimitOrder(&model.OrderLimit{
+		ID:      "2",
+		OwnerID: "alice",
+		Units:   decimal.NewFromInt(3),
+		Price:   decimal.NewFromInt(110),
+		Side:    model.OrderSide_Buy,
+	})
+	if len(r.Trades) != 1 {
+		t.Fatalf("expect 1 trade but got %d", len(r.Trades))
+	}
+	// 2 bought at 100 and 1 resting at 110
+	expectBalance(t, acc, "alice", "USD", 690, 110)
+	expectBalance(t, acc, "alice", "BTC", 2, 0)
+	expectBalance(t, acc, "bob", "BTC", 3, 0)
+	expectBalance(t, acc, "bob", "USD", 200, 0)
+}
+
+func TestInsufficientFundsRejected(t *testing.T) {
+	engine, acc := newFundedEngine()
+
+	r := engine.ProcessLimitOrder(&model.OrderLimit{
+		ID:      "1",
+		OwnerID: "bob",
+		Units:   decimal.NewFromInt(6),
+		Price:   decimal.NewFromInt(100),
+		Side:    model.OrderSide_Sell,
+	})
+	if len(r.Rejections) != 1 || r.Rejections[0].Reason != model.RejectReason_InsufficientFunds {
+		t.Fatalf("expect sell beyond funds to be rejected but got %+v", r)
+	}
+
+	for i, price := range []int64{400, 700} {
+		engine.ProcessLimitOrder(&model.OrderLimit{
+			ID:      fmt.Sprint(i + 2),
+			OwnerID: "bob",
+			Units:   decimal.NewFromInt(1),
+			Price:   decimal.NewFromInt(price),
+			Side:    model.OrderSide_Sell,
+		})
+	}
+
+	// sweeping both sells costs 1100
+	r = engine.ProcessMarketOrder(&model.OrderMarket{
+		ID:      "4",
+		OwnerID: "alice",
+		Units:   decimal.NewFromInt(2),
+		Side:    model.OrderSide_Buy,
+	})
+	if len(r.Trades) != 0 || len(r.Rejections) != 1 || r.Rejections[0].Reason != model.RejectReason_InsufficientFunds {
+		t.Fatalf("expect market buy beyond funds to be rejected but got %+v", r)
+	}
+	expectBalance(t, acc, "alice", "USD", 1000, 0)
+
+	r = engine.ProcessMarketOrder(&model.OrderMarket{
+		ID:      "5",
+		OwnerID: "alice",
+		Units:   decimal.NewFromInt(1),
+		Side:    model.OrderSide_Buy,
+	})
+	if len(r.Trades) != 1 {
+		t.Fatalf("expect market buy within funds to trade but got %+v", r)
+	}
+	expectBalance(t, acc, "alice", "USD", 600, 0)
+	expectBalance(t, acc, "alice", "BTC", 1, 0)
+}
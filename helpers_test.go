@@ -0,0 +1,17 @@
+package matchingenginecore_test
+
+import (
+	me "github.com/dylantkx/matching-engine-core"
+	"github.com/dylantkx/matching-engine-core/model"
+	"github.com/shopspring/decimal"
+)
+
+// addLimit submits a good-till-cancel limit order without an owner.
+func addLimit(engine *me.MatchingEngine, id string, side model.OrderSide, units, price float64) model.MatchResult {
+	return engine.ProcessLimitOrder(&model.OrderLimit{
+		ID:    id,
+		Units: decimal.NewFromFloat(units),
+		Price: decimal.NewFromFloat(price),
+		Side:  side,
+	})
+}
@@ -0,0 +1,64 @@
+package matchingenginecore
+
+import "github.com/dylantkx/matching-engine-core/model"
+
+// checkLimitOrder returns why order breaks the instrument rules or cannot be
+// held by the book, or an empty reason if it is accepted.
+func (me *MatchingEngine) checkLimitOrder(order *model.OrderLimit) model.RejectReason {
+	switch order.TimeInForce {
+	case "", model.TimeInForce_GTC, model.TimeInForce_IOC, model.TimeInForce_FOK, model.TimeInForce_GTD:
+	default:
+		return model.RejectReason_InvalidTimeInForce
+	}
+	if reason := me.rules.CheckOrder(&model.Order{Units: order.Units, Price: order.Price}); reason != "" {
+		return reason
+	}
+	if order.DisplayUnits.IsNegative() {
+		return model.RejectReason_InvalidUnits
+	}
+	if order.DisplayUnits.IsPositive() && me.rules.LotSize.IsPositive() && !order.DisplayUnits.Mod(me.rules.LotSize).IsZero() {
+		return model.RejectReason_InvalidLotSize
+	}
+	if !me.scales.CanRepresentUnits(order.Units) || !me.scales.CanRepresentUnits(order.DisplayUnits) ||
+		!me.scales.CanRepresentPrice(order.Price) {
+		return model.RejectReason_PrecisionExceeded
+	}
+	return ""
+}
+
+// checkMarketOrder checks the units of a market order. Its notional value is
+// not known before it matches, so the minimum notional is only enforced on
+// the budget of an order sized by quote.
+func (me *MatchingEngine) checkMarketOrder(order *model.OrderMarket) model.RejectReason {
+	if order.MaxSlippageBps.IsNegative() || order.ProtectionPrice.IsNegative() {
+		return model.RejectReason_InvalidPrice
+	}
+	if !order.QuoteAmount.IsZero() {
+		if order.Side != model.OrderSide_Buy || !order.QuoteAmount.IsPositive() || !order.Units.IsZero() {
+			return model.RejectReason_InvalidQuoteAmount
+		}
+		if order.QuoteAmount.LessThan(me.rules.MinNotional) {
+			return model.RejectReason_NotionalBelowMin
+		}
+		return ""
+	}
+	if reason := me.rules.CheckUnits(order.Units); reason != "" {
+		return reason
+	}
+	if !me.scales.CanRepresentUnits(order.Units) {
+		return model.RejectReason_PrecisionExceeded
+	}
+	return ""
+}
+
+// checkStopOrder checks a stop order as the order it is released as, along
+// with its stop price.
+func (me *MatchingEngine) checkStopOrder(order *model.OrderStop) model.RejectReason {
+	if reason := me.rules.CheckPrice(order.StopPrice); reason != "" {
+		return reason
+	}
+	if order.Type == model.OrderType_StopLimit {
+		return me.checkLimitOrder(&model.OrderLimit{Units: order.Units, Price: order.Price})
+	}
+	return me.checkMarketOrder(&model.OrderMarket{Units: order.Units})
+}
@@ -0,0 +1,121 @@
+package matchingenginecore_test
+
+import (
+	"errors"
+	"testing"
+
+	me "github.com/dylantkx/matching-engine-core"
+	"github.com/dylantkx/matching-engine-core/model"
+	"github.com/shopspring/decimal"
+)
+
+func newRulesEngine() *me.MatchingEngine {
+	return me.NewMatchingEngine(me.WithInstrumentRules(model.InstrumentRules{
+		TickSize:    decimal.NewFromFloat(0.5),
+		LotSize:     decimal.NewFromFloat(0.1),
+		MinUnits:    decimal.NewFromFloat(0.2),
+		MaxUnits:    decimal.NewFromFloat(100),
+		MinNotional: decimal.NewFromFloat(10),
+		MaxPrice:    decimal.NewFromFloat(1000),
+	}))
+}
+
+func TestLimitOrderRules(t *testing.T) {
+	tests := []struct {
+		units  float64
+		price  float64
+		reason model.RejectReason
+	}{
+		{units: 0, price: 100, reason: model.RejectReason_InvalidUnits},
+		{units: -1, price: 100, reason: model.RejectReason_InvalidUnits},
+		{units: 1, price: 0, reason: model.RejectReason_InvalidPrice},
+		{units: 1, price: -100, reason: model.RejectReason_InvalidPrice},
+		{units: 1, price: 100.2, reason: model.RejectReason_InvalidTickSize},
+		{units: 1.05, price: 100, reason: model.RejectReason_InvalidLotSize},
+		{units: 0.1, price: 100, reason: model.RejectReason_UnitsBelowMin},
+		{units: 101, price: 100, reason: model.RejectReason_UnitsAboveMax},
+		{units: 1, price: 1000.5, reason: model.RejectReason_PriceAboveMax},
+		{units: 0.3, price: 30, reason: model.RejectReason_NotionalBelowMin},
+		{units: 0.5, price: 20},
+	}
+
+	for _, tt := range tests {
+		engine := newRulesEngine()
+		r := engine.ProcessLimitOrder(&model.OrderLimit{
+			ID:    "1",
+			Units: decimal.NewFromFloat(tt.units),
+			Price: decimal.NewFromFloat(tt.price),
+			Side:  model.OrderSide_Buy,
+		})
+		if tt.reason == "" {
+			if len(r.Rejections) != 0 || !engine.GetHighestBuyPrice().Equal(decimal.NewFromFloat(tt.price)) {
+				t.Fatalf("expect %v @ %v to rest but got %+v", tt.units, tt.price, r)
+			}
+			continue
+		}
+		if len(r.Rejections) != 1 || r.Rejections[0].Reason != tt.reason {
+			t.Fatalf("expect %v @ %v to be rejected with %s but got %+v", tt.units, tt.price, tt.reason, r.Rejections)
+		}
+		if len(r.Reports) != 1 || r.Reports[0].OrdStatus != model.OrdStatus_Rejected {
+			t.Fatalf("expect a rejected report but got %+v", r.Reports)
+		}
+		if !engine.GetHighestBuyPrice().IsZero() {
+			t.Fatalf("expect rejected order not to rest, but highest buy is %s", engine.GetHighestBuyPrice())
+		}
+	}
+}
+
+func TestMarketAndStopOrderRules(t *testing.T) {
+	engine := newRulesEngine()
+
+	r := engine.ProcessMarketOrder(&model.OrderMarket{
+		ID:    "1",
+		Units: decimal.NewFromFloat(0.15),
+		Side:  model.OrderSide_Buy,
+	})
+	if len(r.Rejections) != 1 || r.Rejections[0].Reason != model.RejectReason_InvalidLotSize {
+		t.Fatalf("wrong rejection output: %+v", r.Rejections)
+	}
+
+	r = engine.ProcessStopOrder(&model.OrderStop{
+		ID:        "2",
+		Type:      model.OrderType_StopLimit,
+		Units:     decimal.NewFromFloat(1),
+		Price:     decimal.NewFromFloat(100.25),
+		StopPrice: decimal.NewFromFloat(100),
+		Side:      model.OrderSide_Buy,
+	})
+	if len(r.Rejections) != 1 || r.Rejections[0].Reason != model.RejectReason_InvalidTickSize {
+		t.Fatalf("wrong rejection output: %+v", r.Rejections)
+	}
+
+	r = engine.ProcessStopOrder(&model.OrderStop{
+		ID:        "3",
+		Type:      model.OrderType_StopMarket,
+		Units:     decimal.NewFromFloat(1),
+		StopPrice: decimal.NewFromFloat(0),
+		Side:      model.OrderSide_Sell,
+	})
+	if len(r.Rejections) != 1 || r.Rejections[0].Reason != model.RejectReason_InvalidPrice {
+		t.Fatalf("wrong rejection output: %+v", r.Rejections)
+	}
+}
+
+func TestAmendOrderRules(t *testing.T) {
+	engine := newRulesEngine()
+	engine.ProcessLimitOrder(&model.OrderLimit{
+		ID:    "1",
+		Units: decimal.NewFromFloat(1),
+		Price: decimal.NewFromFloat(100),
+		Side:  model.OrderSide_Buy,
+	})
+
+	_, _, err := engine.AmendOrder(&model.OrderAmend{OrderID: "1", Price: decimal.NewFromFloat(100.1)})
+	var rejected *me.OrderRejectedError
+	if !errors.As(err, &rejected) || rejected.Reason != model.RejectReason_InvalidTickSize || !errors.Is(err, me.ErrOrderRejected) {
+		t.Fatalf("expect tick size rejection but got %v", err)
+	}
+	if sn := engine.GetOrderBookFullSnapshot(); len(sn.Buys) != 1 || !sn.Buys[0].Price.Equal(decimal.NewFromFloat(100)) {
+		t.Fatalf("expect order to stay untouched but got %+v", sn.Buys)
+	}
+}
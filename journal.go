@@ -0,0 +1,132 @@
+package matchingenginecore
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// JournalEntry is a command as it was accepted by an engine. Time is the
+// engine clock the command was processed with, kept to the nanosecond.
+type JournalEntry struct {
+	Seq     uint64    `json:"seq"`
+	Time    time.Time `json:"time"`
+	Command Command   `json:"command"`
+}
+
+// Journal is an append-only log of the commands of one engine. Append is
+// called before a command is processed, and a command is not processed unless
+// Append succeeds. The entry must be persisted, not retained: its command
+// points at the caller's order.
+type Journal interface {
+	Append(entry JournalEntry) error
+}
+
+// WriterJournal writes entries to w as newline-delimited JSON.
+type WriterJournal struct {
+	w  io.Writer
+	mu sync.Mutex
+}
+
+func NewWriterJournal(w io.Writer) *WriterJournal {
+	return &WriterJournal{w: w}
+}
+
+func (j *WriterJournal) Append(entry JournalEntry) error {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	_, err = j.w.Write(append(b, '\n'))
+	return err
+}
+
+// FileJournal is a WriterJournal on a file that is synced after every entry.
+type FileJournal struct {
+	*WriterJournal
+	f *os.File
+}
+
+// OpenFileJournal opens the journal at path for appending, creating it if
+// needed.
+func OpenFileJournal(path string) (*FileJournal, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileJournal{WriterJournal: NewWriterJournal(f), f: f}, nil
+}
+
+func (j *FileJournal) Append(entry JournalEntry) error {
+	if err := j.WriterJournal.Append(entry); err != nil {
+		return err
+	}
+	return j.f.Sync()
+}
+
+func (j *FileJournal) Close() error {
+	return j.f.Close()
+}
+
+// ReadJournal reads the entries written by a WriterJournal.
+func ReadJournal(r io.Reader) ([]JournalEntry, error) {
+	var entries []JournalEntry
+	dec := json.NewDecoder(bufio.NewReader(r))
+	for {
+		var entry JournalEntry
+		err := dec.Decode(&entry)
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return entries, err
+		}
+		entries = append(entries, entry)
+	}
+}
+
+// Replay builds an engine with opts and applies entries to it in order, each
+// with the engine clock set to the time it was journaled at. Entries must be
+// numbered from 1 without gaps. They are not journaled again, but commands
+// processed after Replay returns are appended to the journal given in opts,
+// continuing the sequence. Replayed events are not published to event sinks.
+func Replay(entries []JournalEntry, opts ...Option) (*MatchingEngine, error) {
+	me := NewMatchingEngine(opts...)
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	sinks := me.sinks
+	me.sinks = nil
+	defer func() { me.sinks = sinks }()
+	for _, entry := range entries {
+		if entry.Seq != me.seq+1 {
+			return nil, fmt.Errorf("%w: expect entry %d but got %d", ErrJournalSequence, me.seq+1, entry.Seq)
+		}
+		me.seq = entry.Seq
+		me.now = entry.Time
+		me.apply(entry.Command)
+	}
+	return me, nil
+}
+
+// journalCommand appends cmd to the journal, if the engine has one.
+func (me *MatchingEngine) journalCommand(cmd Command) error {
+	if me.journal == nil {
+		return nil
+	}
+	entry := JournalEntry{
+		Seq:     me.seq + 1,
+		Time:    me.now,
+		Command: cmd,
+	}
+	if err := me.journal.Append(entry); err != nil {
+		return err
+	}
+	me.seq = entry.Seq
+	return nil
+}
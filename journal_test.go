@@ -0,0 +1,129 @@
+package matchingenginecore_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	me "github.com/dylantkx/matching-engine-core"
+	"github.com/dylantkx/matching-engine-core/model"
+	"github.com/shopspring/decimal"
+)
+
+type failingJournal struct{}
+
+func (failingJournal) Append(entry me.JournalEntry) error {
+	return errors.New("disk full")
+}
+
+func TestReplayJournal(t *testing.T) {
+	var buf bytes.Buffer
+	engine := me.NewMatchingEngine(me.WithJournal(me.NewWriterJournal(&buf)))
+
+	rnd := rand.New(rand.NewSource(1))
+	start := time.Now()
+	for i := 1; i <= 200; i++ {
+		side := model.OrderSide_Buy
+		if rnd.Intn(2) == 0 {
+			side = model.OrderSide_Sell
+		}
+		units := decimal.NewFromInt(int64(rnd.Intn(5) + 1))
+		switch rnd.Intn(10) {
+		case 0:
+			engine.ProcessMarketOrder(&model.OrderMarket{
+				ID:    fmt.Sprintf("%d", i),
+				Units: units,
+				Side:  side,
+			})
+		case 1:
+			engine.CancelOrderByID(fmt.Sprintf("%d", rnd.Intn(i)+1))
+		case 2:
+			engine.ExpireOrders(time.Now())
+		default:
+			order := model.OrderLimit{
+				ID:    fmt.Sprintf("%d", i),
+				Units: units,
+				Price: decimal.NewFromInt(int64(95 + rnd.Intn(10))),
+				Side:  side,
+			}
+			if rnd.Intn(3) == 0 {
+				order.TimeInForce = model.TimeInForce_GTD
+				order.ExpireTime = model.Timestamp{Time: start.Add(time.Duration(rnd.Intn(2000)) * time.Microsecond)}
+			}
+			engine.ProcessLimitOrder(&order)
+		}
+	}
+
+	entries, err := me.ReadJournal(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if len(entries) != 200 {
+		t.Fatalf("expect 200 journal entries but got %d", len(entries))
+	}
+	replayed, err := me.Replay(entries)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	want, _ := json.Marshal(engine.GetOrderBookFullSnapshot())
+	got, _ := json.Marshal(replayed.GetOrderBookFullSnapshot())
+	if !bytes.Equal(want, got) {
+		t.Fatalf("expect replayed book %s, but got %s", want, got)
+	}
+	if !replayed.GetLastTradePrice().Equal(engine.GetLastTradePrice()) {
+		t.Fatalf("expect last price %s, but got %s", engine.GetLastTradePrice(), replayed.GetLastTradePrice())
+	}
+
+	sweep := func(e *me.MatchingEngine) []model.Trade {
+		r := e.ProcessMarketOrder(&model.OrderMarket{
+			ID:    "sweep",
+			Units: decimal.NewFromInt(1000),
+			Side:  model.OrderSide_Buy,
+		})
+		return r.Trades
+	}
+	wantTrades, gotTrades := sweep(engine), sweep(replayed)
+	if len(wantTrades) != len(gotTrades) {
+		t.Fatalf("expect %d trades after replay but got %d", len(wantTrades), len(gotTrades))
+	}
+	for i := range wantTrades {
+		if wantTrades[i].SellOrderID != gotTrades[i].SellOrderID || !wantTrades[i].Units.Equal(gotTrades[i].Units) {
+			t.Fatalf("expect trade %+v after replay, but got %+v", wantTrades[i], gotTrades[i])
+		}
+	}
+}
+
+func TestReplayRejectsSequenceGap(t *testing.T) {
+	entries := []me.JournalEntry{
+		{Seq: 1, Command: me.Command{Expire: true}},
+		{Seq: 3, Command: me.Command{Expire: true}},
+	}
+	if _, err := me.Replay(entries); !errors.Is(err, me.ErrJournalSequence) {
+		t.Fatalf("expect journal sequence error, but got %v", err)
+	}
+}
+
+func TestOrderRejectedWhenJournalFails(t *testing.T) {
+	engine := me.NewMatchingEngine(me.WithJournal(failingJournal{}))
+
+	r := engine.ProcessLimitOrder(&model.OrderLimit{
+		ID:    "1",
+		Units: decimal.NewFromFloat(1),
+		Price: decimal.NewFromFloat(100),
+		Side:  model.OrderSide_Buy,
+	})
+	if len(r.Rejections) != 1 || r.Rejections[0].Reason != model.RejectReason_NotJournaled {
+		t.Fatalf("expect order to be rejected, but got %+v", r.Rejections)
+	}
+	if !engine.GetHighestBuyPrice().IsZero() {
+		t.Fatalf("expect order not to rest")
+	}
+	if _, err := engine.CancelOrderByID("1"); err == nil {
+		t.Fatalf("expect cancel to fail")
+	}
+}
@@ -0,0 +1,19 @@
+package model
+
+import "github.com/shopspring/decimal"
+
+// AuctionIndicative is the outcome an uncross would have right now: the
+// single price it would trade at, the units it would execute, and the units
+// left unmatched on ImbalanceSide at that price. A zero Price means the book
+// does not cross.
+type AuctionIndicative struct {
+	Price          decimal.Decimal `json:"price"`
+	Units          decimal.Decimal `json:"units"`
+	ImbalanceUnits decimal.Decimal `json:"imbalanceUnits"`
+	ImbalanceSide  OrderSide       `json:"imbalanceSide,omitempty"`
+}
+
+func (a *AuctionIndicative) Equal(other *AuctionIndicative) bool {
+	return a.Price.Equal(other.Price) && a.Units.Equal(other.Units) &&
+		a.ImbalanceUnits.Equal(other.ImbalanceUnits) && a.ImbalanceSide == other.ImbalanceSide
+}
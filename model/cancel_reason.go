@@ -0,0 +1,14 @@
+package model
+
+type CancelReason = string
+
+const (
+	CancelReason_Requested         CancelReason = "REQUESTED"
+	CancelReason_Unfilled          CancelReason = "UNFILLED"
+	CancelReason_ImmediateOrCancel CancelReason = "IMMEDIATE_OR_CANCEL"
+	CancelReason_FillOrKill        CancelReason = "FILL_OR_KILL"
+	CancelReason_Expired           CancelReason = "EXPIRED"
+	CancelReason_SelfTrade         CancelReason = "SELF_TRADE_PREVENTION"
+	CancelReason_PriceBand         CancelReason = "OUTSIDE_PRICE_BAND"
+	CancelReason_Slippage          CancelReason = "SLIPPAGE_PROTECTION"
+)
@@ -0,0 +1,6 @@
+package model
+
+type CancelRequest struct {
+	Symbol  string `json:"symbol,omitempty"`
+	OrderID string `json:"orderId"`
+}
@@ -0,0 +1,19 @@
+package model
+
+import "github.com/shopspring/decimal"
+
+// Candle summarizes the trades of one interval, from OpenTime inclusive to
+// CloseTime exclusive. An interval without trades has a zero TradeCount and
+// all of its prices at the close of the interval before it.
+type Candle struct {
+	Interval    CandleInterval  `json:"interval"`
+	OpenTime    Timestamp       `json:"openTime"`
+	CloseTime   Timestamp       `json:"closeTime"`
+	Open        decimal.Decimal `json:"open"`
+	High        decimal.Decimal `json:"high"`
+	Low         decimal.Decimal `json:"low"`
+	Close       decimal.Decimal `json:"close"`
+	Volume      decimal.Decimal `json:"volume"`
+	QuoteVolume decimal.Decimal `json:"quoteVolume"`
+	TradeCount  int             `json:"tradeCount"`
+}
@@ -0,0 +1,11 @@
+package model
+
+type CandleInterval = string
+
+const (
+	CandleInterval_1s CandleInterval = "1s"
+	CandleInterval_1m CandleInterval = "1m"
+	CandleInterval_5m CandleInterval = "5m"
+	CandleInterval_1h CandleInterval = "1h"
+	CandleInterval_1d CandleInterval = "1d"
+)
@@ -0,0 +1,15 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// CircuitBreaker halts trading for CoolingOff once the highest and lowest
+// trade prices within Window are more than Percent apart.
+type CircuitBreaker struct {
+	Percent    decimal.Decimal `json:"percent"`
+	Window     time.Duration   `json:"window"`
+	CoolingOff time.Duration   `json:"coolingOff"`
+}
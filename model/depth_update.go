@@ -0,0 +1,12 @@
+package model
+
+import "github.com/shopspring/decimal"
+
+// DepthUpdate is the new aggregate size of one price level. A zero Size means
+// the level was removed. Seq numbers every update of a book without gaps.
+type DepthUpdate struct {
+	Seq   uint64          `json:"seq"`
+	Side  OrderSide       `json:"side"`
+	Price decimal.Decimal `json:"price"`
+	Size  decimal.Decimal `json:"size"`
+}
@@ -0,0 +1,27 @@
+package model
+
+type EventType = string
+
+const (
+	EventType_Trade        EventType = "TRADE"
+	EventType_Cancellation EventType = "CANCELLATION"
+	EventType_Rejection    EventType = "REJECTION"
+	EventType_Report       EventType = "REPORT"
+	EventType_DepthUpdate  EventType = "DEPTH_UPDATE"
+	EventType_Indicative   EventType = "INDICATIVE"
+	EventType_SessionState EventType = "SESSION_STATE"
+)
+
+// Event is one thing produced by an engine. Only the field matching Type is
+// set.
+type Event struct {
+	Type         EventType           `json:"type"`
+	Symbol       string              `json:"symbol,omitempty"`
+	Trade        *Trade              `json:"trade,omitempty"`
+	Cancellation *OrderCancellation  `json:"cancellation,omitempty"`
+	Rejection    *OrderRejection     `json:"rejection,omitempty"`
+	Report       *ExecutionReport    `json:"report,omitempty"`
+	DepthUpdate  *DepthUpdate        `json:"depthUpdate,omitempty"`
+	Indicative   *AuctionIndicative  `json:"indicative,omitempty"`
+	SessionState *SessionStateChange `json:"sessionState,omitempty"`
+}
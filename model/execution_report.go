@@ -0,0 +1,52 @@
+package model
+
+import "github.com/shopspring/decimal"
+
+// ExecType is what happened to an order, following FIX tag 150.
+type ExecType = string
+
+const (
+	ExecType_New       ExecType = "NEW"
+	ExecType_Trade     ExecType = "TRADE"
+	ExecType_Canceled  ExecType = "CANCELED"
+	ExecType_Replaced  ExecType = "REPLACED"
+	ExecType_Restated  ExecType = "RESTATED"
+	ExecType_Rejected  ExecType = "REJECTED"
+	ExecType_Expired   ExecType = "EXPIRED"
+	ExecType_Triggered ExecType = "TRIGGERED"
+)
+
+// OrdStatus is the state of an order after an execution, following FIX tag 39.
+type OrdStatus = string
+
+const (
+	OrdStatus_New             OrdStatus = "NEW"
+	OrdStatus_PartiallyFilled OrdStatus = "PARTIALLY_FILLED"
+	OrdStatus_Filled          OrdStatus = "FILLED"
+	OrdStatus_Canceled        OrdStatus = "CANCELED"
+	OrdStatus_Rejected        OrdStatus = "REJECTED"
+	OrdStatus_Expired         OrdStatus = "EXPIRED"
+)
+
+// ExecutionReport tells the owner of an order how it changed. LastUnits,
+// LastPrice and Fee are only set for ExecType_Trade, and Reason only for
+// cancellations and rejections. LeavesQuote is the unspent budget of a market
+// buy sized by quote.
+type ExecutionReport struct {
+	Symbol      string          `json:"symbol,omitempty"`
+	OrderID     string          `json:"orderId"`
+	OwnerID     string          `json:"ownerId,omitempty"`
+	Side        OrderSide       `json:"side"`
+	ExecType    ExecType        `json:"execType"`
+	OrdStatus   OrdStatus       `json:"ordStatus"`
+	Price       decimal.Decimal `json:"price"`
+	LastUnits   decimal.Decimal `json:"lastUnits"`
+	LastPrice   decimal.Decimal `json:"lastPrice"`
+	CumUnits    decimal.Decimal `json:"cumUnits"`
+	LeavesUnits decimal.Decimal `json:"leavesUnits"`
+	LeavesQuote decimal.Decimal `json:"leavesQuote"`
+	AvgPrice    decimal.Decimal `json:"avgPrice"`
+	Fee         *Fee            `json:"fee,omitempty"`
+	Reason      string          `json:"reason,omitempty"`
+	EventTime   Timestamp       `json:"eventTime"`
+}
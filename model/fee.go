@@ -0,0 +1,23 @@
+package model
+
+import "github.com/shopspring/decimal"
+
+// Fee is what one side of a trade pays, in the asset it receives. A negative
+// amount is a rebate.
+type Fee struct {
+	Amount decimal.Decimal `json:"amount"`
+	Asset  string          `json:"asset,omitempty"`
+	Rate   decimal.Decimal `json:"rate"`
+}
+
+type TradeFees struct {
+	Buyer  Fee `json:"buyer"`
+	Seller Fee `json:"seller"`
+}
+
+// FeeRevenue is what an instrument has earned in fees, net of rebates. The
+// buyers pay in base and the sellers in quote.
+type FeeRevenue struct {
+	Base  decimal.Decimal `json:"base"`
+	Quote decimal.Decimal `json:"quote"`
+}
@@ -0,0 +1,62 @@
+package model
+
+import "github.com/shopspring/decimal"
+
+type RoundingMode = string
+
+const (
+	RoundingMode_HalfUp   RoundingMode = "HALF_UP"
+	RoundingMode_HalfEven RoundingMode = "HALF_EVEN"
+	// RoundingMode_Up rounds away from zero.
+	RoundingMode_Up RoundingMode = "UP"
+	// RoundingMode_Down rounds towards zero.
+	RoundingMode_Down RoundingMode = "DOWN"
+)
+
+// FeeTier applies to owners who traded at least MinVolume, counted in quote,
+// over the last 30 days. Rates are fractions of what the owner receives, and
+// a negative maker rate pays a rebate.
+type FeeTier struct {
+	MinVolume decimal.Decimal `json:"minVolume"`
+	MakerRate decimal.Decimal `json:"makerRate"`
+	TakerRate decimal.Decimal `json:"takerRate"`
+}
+
+type FeeSchedule struct {
+	Tiers []FeeTier `json:"tiers"`
+	// AccountTiers replaces Tiers for the owners it lists.
+	AccountTiers map[string][]FeeTier `json:"accountTiers,omitempty"`
+	// Fees are rounded to Places decimal places, half up unless Rounding
+	// says otherwise.
+	Places   int32        `json:"places"`
+	Rounding RoundingMode `json:"rounding,omitempty"`
+}
+
+// Tier returns the highest tier an owner with volume qualifies for. No tier
+// means no fees.
+func (s FeeSchedule) Tier(ownerID string, volume decimal.Decimal) FeeTier {
+	tiers, ok := s.AccountTiers[ownerID]
+	if !ok {
+		tiers = s.Tiers
+	}
+	var best FeeTier
+	found := false
+	for _, t := range tiers {
+		if t.MinVolume.LessThanOrEqual(volume) && (!found || t.MinVolume.GreaterThan(best.MinVolume)) {
+			best, found = t, true
+		}
+	}
+	return best
+}
+
+func (s FeeSchedule) Round(fee decimal.Decimal) decimal.Decimal {
+	switch s.Rounding {
+	case RoundingMode_HalfEven:
+		return fee.RoundBank(s.Places)
+	case RoundingMode_Up:
+		return fee.RoundUp(s.Places)
+	case RoundingMode_Down:
+		return fee.RoundDown(s.Places)
+	}
+	return fee.Round(s.Places)
+}
@@ -0,0 +1,56 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/dylantkx/matching-engine-core/model"
+	"github.com/shopspring/decimal"
+)
+
+func TestFeeScheduleTier(t *testing.T) {
+	s := model.FeeSchedule{
+		Tiers: []model.FeeTier{
+			{MinVolume: decimal.NewFromInt(1000), TakerRate: decimal.NewFromFloat(0.001)},
+			{MinVolume: decimal.Zero, TakerRate: decimal.NewFromFloat(0.002)},
+		},
+		AccountTiers: map[string][]model.FeeTier{
+			"vip": {{TakerRate: decimal.NewFromFloat(0.0005)}},
+		},
+	}
+	tests := []struct {
+		ownerID string
+		volume  int64
+		rate    float64
+	}{
+		{"alice", 0, 0.002},
+		{"alice", 999, 0.002},
+		{"alice", 1000, 0.001},
+		{"vip", 0, 0.0005},
+	}
+	for _, tt := range tests {
+		rate := s.Tier(tt.ownerID, decimal.NewFromInt(tt.volume)).TakerRate
+		if !rate.Equal(decimal.NewFromFloat(tt.rate)) {
+			t.Fatalf("expect %s with volume %d to pay %v but got %s", tt.ownerID, tt.volume, tt.rate, rate)
+		}
+	}
+}
+
+func TestFeeScheduleRound(t *testing.T) {
+	fee := decimal.NewFromFloat(0.125)
+	tests := map[model.RoundingMode]string{
+		"":                          "0.13",
+		model.RoundingMode_HalfUp:   "0.13",
+		model.RoundingMode_HalfEven: "0.12",
+		model.RoundingMode_Up:       "0.13",
+		model.RoundingMode_Down:     "0.12",
+	}
+	for mode, expected := range tests {
+		got := model.FeeSchedule{Places: 2, Rounding: mode}.Round(fee)
+		if got.String() != expected {
+			t.Fatalf("expect %s rounding to give %s but got %s", mode, expected, got)
+		}
+	}
+	if got := (model.FeeSchedule{Places: 2, Rounding: model.RoundingMode_Up}).Round(decimal.NewFromFloat(-0.121)); got.String() != "-0.13" {
+		t.Fatalf("expect rebates to round away from zero but got %s", got)
+	}
+}
@@ -0,0 +1,58 @@
+package model
+
+import "github.com/shopspring/decimal"
+
+// InstrumentRules are the limits orders of an instrument must respect. A zero
+// value leaves the corresponding limit unchecked.
+type InstrumentRules struct {
+	TickSize    decimal.Decimal `json:"tickSize"`
+	LotSize     decimal.Decimal `json:"lotSize"`
+	MinUnits    decimal.Decimal `json:"minUnits"`
+	MaxUnits    decimal.Decimal `json:"maxUnits"`
+	MinNotional decimal.Decimal `json:"minNotional"`
+	MaxPrice    decimal.Decimal `json:"maxPrice"`
+}
+
+// CheckUnits returns why units break the rules, or an empty reason if they do
+// not. Units must always be positive.
+func (r *InstrumentRules) CheckUnits(units decimal.Decimal) RejectReason {
+	switch {
+	case !units.IsPositive():
+		return RejectReason_InvalidUnits
+	case r.LotSize.IsPositive() && !units.Mod(r.LotSize).IsZero():
+		return RejectReason_InvalidLotSize
+	case r.MinUnits.IsPositive() && units.LessThan(r.MinUnits):
+		return RejectReason_UnitsBelowMin
+	case r.MaxUnits.IsPositive() && units.GreaterThan(r.MaxUnits):
+		return RejectReason_UnitsAboveMax
+	}
+	return ""
+}
+
+// CheckPrice returns why price breaks the rules, or an empty reason if it
+// does not. Prices must always be positive.
+func (r *InstrumentRules) CheckPrice(price decimal.Decimal) RejectReason {
+	switch {
+	case !price.IsPositive():
+		return RejectReason_InvalidPrice
+	case r.TickSize.IsPositive() && !price.Mod(r.TickSize).IsZero():
+		return RejectReason_InvalidTickSize
+	case r.MaxPrice.IsPositive() && price.GreaterThan(r.MaxPrice):
+		return RejectReason_PriceAboveMax
+	}
+	return ""
+}
+
+// CheckOrder checks the units, price and notional value of an order.
+func (r *InstrumentRules) CheckOrder(order *Order) RejectReason {
+	if reason := r.CheckUnits(order.Units); reason != "" {
+		return reason
+	}
+	if reason := r.CheckPrice(order.Price); reason != "" {
+		return reason
+	}
+	if r.MinNotional.IsPositive() && order.GetVolume().LessThan(r.MinNotional) {
+		return RejectReason_NotionalBelowMin
+	}
+	return ""
+}
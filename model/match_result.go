@@ -3,4 +3,28 @@ package model
 type MatchResult struct {
 	Trades        []Trade             `json:"trades"`
 	Cancellations []OrderCancellation `json:"cancellations"`
+	Rejections    []OrderRejection    `json:"rejections"`
+	Reports       []ExecutionReport   `json:"reports"`
+	DepthUpdates  []DepthUpdate       `json:"depthUpdates"`
+
+	// Indicative is set when a command changes the indicative uncross of an
+	// auction.
+	Indicative *AuctionIndicative `json:"indicative,omitempty"`
+	// SessionState is set when the command moved the session to a new state.
+	SessionState *SessionStateChange `json:"sessionState,omitempty"`
+}
+
+// Append adds everything reported in other to r.
+func (r *MatchResult) Append(other MatchResult) {
+	r.Trades = append(r.Trades, other.Trades...)
+	r.Cancellations = append(r.Cancellations, other.Cancellations...)
+	r.Rejections = append(r.Rejections, other.Rejections...)
+	r.Reports = append(r.Reports, other.Reports...)
+	r.DepthUpdates = append(r.DepthUpdates, other.DepthUpdates...)
+	if other.Indicative != nil {
+		r.Indicative = other.Indicative
+	}
+	if other.SessionState != nil {
+		r.SessionState = other.SessionState
+	}
 }
@@ -2,22 +2,36 @@ package model
 
 import "github.com/shopspring/decimal"
 
+// Order is an order resting on the book. For an iceberg order DisplayUnits is
+// the size of each visible slice, Units is the slice currently on display and
+// HiddenUnits is the reserve used to replenish it.
 type Order struct {
-	ID    string
-	Units decimal.Decimal
-	Price decimal.Decimal
-	Side  OrderSide
+	ID           string
+	OwnerID      string
+	Units        decimal.Decimal
+	Price        decimal.Decimal
+	Side         OrderSide
+	DisplayUnits decimal.Decimal
+	HiddenUnits  decimal.Decimal
 }
 
 func (o *Order) GetVolume() decimal.Decimal {
 	return o.Units.Mul(o.Price)
 }
 
+// GetTotalUnits returns the displayed and hidden units together.
+func (o *Order) GetTotalUnits() decimal.Decimal {
+	return o.Units.Add(o.HiddenUnits)
+}
+
 func (o *Order) Clone() Order {
 	return Order{
-		ID:    o.ID,
-		Units: o.Units.Copy(),
-		Price: o.Price.Copy(),
-		Side:  o.Side,
+		ID:           o.ID,
+		OwnerID:      o.OwnerID,
+		Units:        o.Units.Copy(),
+		Price:        o.Price.Copy(),
+		Side:         o.Side,
+		DisplayUnits: o.DisplayUnits.Copy(),
+		HiddenUnits:  o.HiddenUnits.Copy(),
 	}
 }
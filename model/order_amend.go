@@ -0,0 +1,21 @@
+package model
+
+import "github.com/shopspring/decimal"
+
+// OrderAmend changes the price and/or the remaining units of a resting limit
+// order. A zero Price or Units leaves that attribute unchanged.
+type OrderAmend struct {
+	Symbol  string          `json:"symbol,omitempty"`
+	OrderID string          `json:"orderId"`
+	Units   decimal.Decimal `json:"units"`
+	Price   decimal.Decimal `json:"price"`
+}
+
+// OrderAmendAck confirms an amend. KeptPriority is false when the order was
+// requeued, which happens on a price change or a quantity increase.
+type OrderAmendAck struct {
+	OrderID      string          `json:"orderId"`
+	Units        decimal.Decimal `json:"units"`
+	Price        decimal.Decimal `json:"price"`
+	KeptPriority bool            `json:"keptPriority"`
+}
@@ -2,7 +2,11 @@ package model
 
 import "github.com/shopspring/decimal"
 
+// OrderCancellation takes Units off an order. For a market buy sized by quote
+// it gives back the unspent QuoteAmount instead.
 type OrderCancellation struct {
-	OrderID string          `json:"orderId"`
-	Units   decimal.Decimal `json:"units"`
+	OrderID     string          `json:"orderId"`
+	Units       decimal.Decimal `json:"units"`
+	QuoteAmount decimal.Decimal `json:"quoteAmount"`
+	Reason      CancelReason    `json:"reason,omitempty"`
 }
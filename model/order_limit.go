@@ -2,9 +2,28 @@ package model
 
 import "github.com/shopspring/decimal"
 
+// OrderLimit rests any unfilled units on the book unless TimeInForce says
+// otherwise. An empty TimeInForce is treated as TimeInForce_GTC, and
+// ExpireTime is only used with TimeInForce_GTD. ExpireTime is kept in whole
+// seconds like any Timestamp, so a fraction of a second is dropped and the
+// order expires at the start of that second.
+//
+// A PostOnly order never takes liquidity. If it would cross the opposite best
+// price it is rejected, or with PostOnlyMode_Slide repriced one tick behind
+// the opposite best price before resting.
+//
+// A positive DisplayUnits makes the order an iceberg: only slices of that size
+// are shown on the book, and hidden units are not counted in depth totals.
 type OrderLimit struct {
-	ID    string          `json:"id"`
-	Units decimal.Decimal `json:"units"`
-	Price decimal.Decimal `json:"price"`
-	Side  OrderSide       `json:"side"`
+	ID           string          `json:"id"`
+	Symbol       string          `json:"symbol,omitempty"`
+	OwnerID      string          `json:"ownerId,omitempty"`
+	Units        decimal.Decimal `json:"units"`
+	Price        decimal.Decimal `json:"price"`
+	Side         OrderSide       `json:"side"`
+	TimeInForce  TimeInForce     `json:"timeInForce,omitempty"`
+	ExpireTime   Timestamp       `json:"expireTime"`
+	PostOnly     bool            `json:"postOnly,omitempty"`
+	PostOnlyMode PostOnlyMode    `json:"postOnlyMode,omitempty"`
+	DisplayUnits decimal.Decimal `json:"displayUnits"`
 }
@@ -2,8 +2,22 @@ package model
 
 import "github.com/shopspring/decimal"
 
+// OrderMarket takes Units at any price. A buy can instead be sized by a
+// positive QuoteAmount, spending up to that much quote with Units left zero.
+//
+// A positive MaxSlippageBps, counted from the opposite best price on arrival,
+// or ProtectionPrice stops the order from trading beyond that price; with
+// both, the tighter one applies. What is left is cancelled, or with
+// MarketToLimit rests as a limit order at the last price the order traded
+// at. An order sized by quote is never converted.
 type OrderMarket struct {
-	ID    string          `json:"id"`
-	Units decimal.Decimal `json:"units"`
-	Side  OrderSide       `json:"side"`
+	ID              string          `json:"id"`
+	Symbol          string          `json:"symbol,omitempty"`
+	OwnerID         string          `json:"ownerId,omitempty"`
+	Units           decimal.Decimal `json:"units"`
+	QuoteAmount     decimal.Decimal `json:"quoteAmount"`
+	Side            OrderSide       `json:"side"`
+	MaxSlippageBps  decimal.Decimal `json:"maxSlippageBps"`
+	ProtectionPrice decimal.Decimal `json:"protectionPrice"`
+	MarketToLimit   bool            `json:"marketToLimit,omitempty"`
 }
@@ -0,0 +1,6 @@
+package model
+
+type OrderRejection struct {
+	OrderID string       `json:"orderId"`
+	Reason  RejectReason `json:"reason"`
+}
@@ -0,0 +1,24 @@
+package model
+
+import "github.com/shopspring/decimal"
+
+// OrderStop is held off the book until a trade prints at or through
+// StopPrice (at or above for buys, at or below for sells). It is then released
+// as a market order, or as a limit order at Price for OrderType_StopLimit.
+type OrderStop struct {
+	ID        string          `json:"id"`
+	Symbol    string          `json:"symbol,omitempty"`
+	OwnerID   string          `json:"ownerId,omitempty"`
+	Type      OrderType       `json:"type"`
+	Units     decimal.Decimal `json:"units"`
+	Price     decimal.Decimal `json:"price"`
+	StopPrice decimal.Decimal `json:"stopPrice"`
+	Side      OrderSide       `json:"side"`
+}
+
+func (o *OrderStop) IsTriggeredBy(price decimal.Decimal) bool {
+	if o.Side == OrderSide_Buy {
+		return price.GreaterThanOrEqual(o.StopPrice)
+	}
+	return price.LessThanOrEqual(o.StopPrice)
+}
@@ -3,6 +3,8 @@ package model
 type OrderType = string
 
 const (
-	OrderType_Market OrderType = "MARKET"
-	OrderType_Limit  OrderType = "LIMIT"
+	OrderType_Market     OrderType = "MARKET"
+	OrderType_Limit      OrderType = "LIMIT"
+	OrderType_StopMarket OrderType = "STOP_MARKET"
+	OrderType_StopLimit  OrderType = "STOP_LIMIT"
 )
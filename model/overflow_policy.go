@@ -0,0 +1,10 @@
+package model
+
+type OverflowPolicy = string
+
+const (
+	// OverflowPolicy_Drop makes room for a new item by dropping the oldest.
+	OverflowPolicy_Drop OverflowPolicy = "DROP"
+	// OverflowPolicy_Block waits until there is room for a new item.
+	OverflowPolicy_Block OverflowPolicy = "BLOCK"
+)
@@ -0,0 +1,8 @@
+package model
+
+type PostOnlyMode = string
+
+const (
+	PostOnlyMode_Reject PostOnlyMode = "REJECT"
+	PostOnlyMode_Slide  PostOnlyMode = "SLIDE"
+)
@@ -0,0 +1,20 @@
+package model
+
+import "github.com/shopspring/decimal"
+
+type PriceBandMode = string
+
+const (
+	// PriceBandMode_Reject rejects an order that would trade outside the band.
+	PriceBandMode_Reject PriceBandMode = "REJECT"
+	// PriceBandMode_Truncate fills an order up to the edge of the band and
+	// cancels the rest.
+	PriceBandMode_Truncate PriceBandMode = "TRUNCATE"
+)
+
+// PriceBand keeps trades within Percent of the reference price, which is the
+// last trade or auction price.
+type PriceBand struct {
+	Percent decimal.Decimal `json:"percent"`
+	Mode    PriceBandMode   `json:"mode"`
+}
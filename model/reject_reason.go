@@ -0,0 +1,25 @@
+package model
+
+type RejectReason = string
+
+const (
+	RejectReason_PostOnlyWouldCross RejectReason = "POST_ONLY_WOULD_CROSS"
+	RejectReason_NotJournaled       RejectReason = "NOT_JOURNALED"
+	RejectReason_PrecisionExceeded  RejectReason = "PRECISION_EXCEEDED"
+	RejectReason_InvalidUnits       RejectReason = "INVALID_UNITS"
+	RejectReason_InvalidPrice       RejectReason = "INVALID_PRICE"
+	RejectReason_InvalidTickSize    RejectReason = "INVALID_TICK_SIZE"
+	RejectReason_InvalidLotSize     RejectReason = "INVALID_LOT_SIZE"
+	RejectReason_UnitsBelowMin      RejectReason = "UNITS_BELOW_MIN"
+	RejectReason_UnitsAboveMax      RejectReason = "UNITS_ABOVE_MAX"
+	RejectReason_PriceAboveMax      RejectReason = "PRICE_ABOVE_MAX"
+	RejectReason_NotionalBelowMin   RejectReason = "NOTIONAL_BELOW_MIN"
+	RejectReason_AuctionInProgress  RejectReason = "AUCTION_IN_PROGRESS"
+	RejectReason_SessionHalted      RejectReason = "SESSION_HALTED"
+	RejectReason_SessionClosed      RejectReason = "SESSION_CLOSED"
+	RejectReason_PriceBand          RejectReason = "OUTSIDE_PRICE_BAND"
+	RejectReason_InsufficientFunds  RejectReason = "INSUFFICIENT_FUNDS"
+	RejectReason_InvalidQuoteAmount RejectReason = "INVALID_QUOTE_AMOUNT"
+	RejectReason_DuplicateOrderID   RejectReason = "DUPLICATE_ORDER_ID"
+	RejectReason_InvalidTimeInForce RejectReason = "INVALID_TIME_IN_FORCE"
+)
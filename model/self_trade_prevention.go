@@ -0,0 +1,19 @@
+package model
+
+// SelfTradePrevention decides what happens when an incoming order would
+// match a resting order of the same owner. The empty mode allows self trades.
+type SelfTradePrevention = string
+
+const (
+	// SelfTradePrevention_CancelNewest cancels the rest of the incoming order.
+	SelfTradePrevention_CancelNewest SelfTradePrevention = "CANCEL_NEWEST"
+	// SelfTradePrevention_CancelOldest cancels the resting order and keeps
+	// matching the incoming order.
+	SelfTradePrevention_CancelOldest SelfTradePrevention = "CANCEL_OLDEST"
+	// SelfTradePrevention_CancelBoth cancels the resting order and the rest
+	// of the incoming order.
+	SelfTradePrevention_CancelBoth SelfTradePrevention = "CANCEL_BOTH"
+	// SelfTradePrevention_DecrementAndCancel takes the smaller quantity off
+	// both orders, cancelling whichever is used up.
+	SelfTradePrevention_DecrementAndCancel SelfTradePrevention = "DECREMENT_AND_CANCEL"
+)
@@ -0,0 +1,7 @@
+package model
+
+// SessionRequest asks the engine of Symbol to move to State.
+type SessionRequest struct {
+	Symbol string       `json:"symbol,omitempty"`
+	State  SessionState `json:"state"`
+}
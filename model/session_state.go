@@ -0,0 +1,10 @@
+package model
+
+type SessionState = string
+
+const (
+	SessionState_PreOpen SessionState = "PRE_OPEN"
+	SessionState_Open    SessionState = "OPEN"
+	SessionState_Halted  SessionState = "HALTED"
+	SessionState_Closed  SessionState = "CLOSED"
+)
@@ -0,0 +1,17 @@
+package model
+
+type SessionChangeReason = string
+
+const (
+	SessionChangeReason_CircuitBreaker  SessionChangeReason = "CIRCUIT_BREAKER"
+	SessionChangeReason_CoolingOffEnded SessionChangeReason = "COOLING_OFF_ENDED"
+)
+
+// SessionStateChange reports a move between session states. Reason is empty
+// for changes made through SetSessionState.
+type SessionStateChange struct {
+	From      SessionState        `json:"from"`
+	To        SessionState        `json:"to"`
+	Reason    SessionChangeReason `json:"reason,omitempty"`
+	EventTime Timestamp           `json:"eventTime"`
+}
@@ -0,0 +1,23 @@
+package model
+
+import "github.com/shopspring/decimal"
+
+// Ticker summarizes the trades of a rolling window ending at CloseTime.
+// LastPrice and LastUnits are those of the last trade even when it is older
+// than the window, in which case every other figure is zero.
+type Ticker struct {
+	Symbol             string          `json:"symbol,omitempty"`
+	LastPrice          decimal.Decimal `json:"lastPrice"`
+	LastUnits          decimal.Decimal `json:"lastUnits"`
+	Open               decimal.Decimal `json:"open"`
+	High               decimal.Decimal `json:"high"`
+	Low                decimal.Decimal `json:"low"`
+	Volume             decimal.Decimal `json:"volume"`
+	QuoteVolume        decimal.Decimal `json:"quoteVolume"`
+	PriceChange        decimal.Decimal `json:"priceChange"`
+	PriceChangePercent decimal.Decimal `json:"priceChangePercent"`
+	VWAP               decimal.Decimal `json:"vwap"`
+	TradeCount         int             `json:"tradeCount"`
+	OpenTime           Timestamp       `json:"openTime"`
+	CloseTime          Timestamp       `json:"closeTime"`
+}
@@ -0,0 +1,10 @@
+package model
+
+type TimeInForce = string
+
+const (
+	TimeInForce_GTC TimeInForce = "GTC"
+	TimeInForce_IOC TimeInForce = "IOC"
+	TimeInForce_FOK TimeInForce = "FOK"
+	TimeInForce_GTD TimeInForce = "GTD"
+)
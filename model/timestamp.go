@@ -1,6 +1,7 @@
 package model
 
 import (
+	"fmt"
 	"strconv"
 	"time"
 )
@@ -17,3 +18,13 @@ func (t Timestamp) MarshalJSON() ([]byte, error) {
 	s := strconv.Itoa(int(t.Unix()))
 	return []byte(s), nil
 }
+
+// UnmarshalJSON reads whole Unix seconds, as written by MarshalJSON.
+func (t *Timestamp) UnmarshalJSON(b []byte) error {
+	sec, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp %s: %w", b, err)
+	}
+	t.Time = time.Unix(sec, 0)
+	return nil
+}
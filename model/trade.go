@@ -5,10 +5,12 @@ import (
 )
 
 type Trade struct {
+	Symbol       string          `json:"symbol,omitempty"`
 	BuyOrderID   string          `json:"buyOrderId"`
 	SellOrderID  string          `json:"sellOrderId"`
 	Units        decimal.Decimal `json:"units"`
 	Price        decimal.Decimal `json:"price"`
 	IsBuyerMaker bool            `json:"isBuyerMaker"`
 	EventTime    Timestamp       `json:"eventTime"`
+	Fees         *TradeFees      `json:"fees,omitempty"`
 }
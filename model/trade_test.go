@@ -28,3 +28,36 @@ func TestMarshalTrade(t *testing.T) {
 		t.Fatalf("wrong output, got %s", b)
 	}
 }
+
+func TestTimestampRoundTrip(t *testing.T) {
+	for _, ts := range []model.Timestamp{
+		{Time: time.Unix(1663079295, 0)},
+		{Time: time.Unix(-1, 0)},
+	} {
+		b, err := json.Marshal(ts)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		var got model.Timestamp
+		if err := json.Unmarshal(b, &got); err != nil {
+			t.Fatalf(err.Error())
+		}
+		if !got.Time.Equal(ts.Time) {
+			t.Fatalf("expect %s after round trip, but got %s", ts.Time, got.Time)
+		}
+	}
+}
+
+func TestTimestampMarshalsWholeSeconds(t *testing.T) {
+	b, err := json.Marshal(model.Timestamp{Time: time.Unix(1663079295, 120000000)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if string(b) != "1663079295" {
+		t.Fatalf("expect 1663079295 but got %s", b)
+	}
+	var got model.Timestamp
+	if err := json.Unmarshal([]byte("1663079295.12"), &got); err == nil {
+		t.Fatalf("expect fractional seconds to be rejected, but got %s", got.Time)
+	}
+}
@@ -0,0 +1,151 @@
+package matchingenginecore
+
+import (
+	"time"
+
+	"github.com/dylantkx/matching-engine-core/account"
+	"github.com/dylantkx/matching-engine-core/candle"
+	"github.com/dylantkx/matching-engine-core/model"
+	"github.com/dylantkx/matching-engine-core/orderbook"
+	"github.com/shopspring/decimal"
+)
+
+type Option func(me *MatchingEngine)
+
+// WithSymbol names the instrument the engine trades. It is stamped on trades
+// and execution reports.
+func WithSymbol(symbol string) Option {
+	return func(me *MatchingEngine) {
+		me.symbol = symbol
+	}
+}
+
+// WithTickSize sets the minimum price increment of the instrument. Orders
+// priced off the tick are rejected, and post-only orders are slid one tick
+// behind the opposite best price.
+func WithTickSize(tickSize decimal.Decimal) Option {
+	return func(me *MatchingEngine) {
+		me.rules.TickSize = tickSize
+	}
+}
+
+// WithInstrumentRules sets the limits that orders of the instrument are
+// checked against before matching. It replaces a tick size set before it.
+func WithInstrumentRules(rules model.InstrumentRules) Option {
+	return func(me *MatchingEngine) {
+		me.rules = rules
+	}
+}
+
+// WithSelfTradePrevention stops orders of the same owner from trading with
+// each other in continuous trading. Orders without an owner are never
+// affected, and neither is the uncross of an auction.
+func WithSelfTradePrevention(mode model.SelfTradePrevention) Option {
+	return func(me *MatchingEngine) {
+		me.selfTradePrevention = mode
+	}
+}
+
+// WithJournal writes every command to j before it is processed, so that the
+// engine can be rebuilt with Replay.
+func WithJournal(j Journal) Option {
+	return func(me *MatchingEngine) {
+		me.journal = j
+	}
+}
+
+// WithEventSink publishes everything the engine produces to sink, in the
+// order it happens. The option can be given more than once.
+func WithEventSink(sink EventSink) Option {
+	return func(me *MatchingEngine) {
+		me.sinks = append(me.sinks, sink)
+	}
+}
+
+// WithCandles feeds every trade of the engine to agg. Unlike an event sink,
+// it also sees the trades of a replay.
+func WithCandles(agg *candle.Aggregator) Option {
+	return func(me *MatchingEngine) {
+		me.candles = agg
+	}
+}
+
+// WithTickerWindow sets how far back GetTicker looks. It defaults to 24 hours.
+func WithTickerWindow(window time.Duration) Option {
+	return func(me *MatchingEngine) {
+		me.ticker = newRollingTicker(window)
+	}
+}
+
+// WithScales sets the decimal places prices and units of the instrument are
+// kept with. Orders with more decimal places are rejected. It defaults to
+// orderbook.DefaultScales.
+func WithScales(scales orderbook.Scales) Option {
+	return func(me *MatchingEngine) {
+		me.scales = scales
+	}
+}
+
+// WithOpeningAuction starts the engine in the pre-open session, collecting
+// orders for an opening auction, see StartAuction.
+func WithOpeningAuction() Option {
+	return WithSessionState(model.SessionState_PreOpen)
+}
+
+// WithSessionState sets the session state the engine starts in. It defaults
+// to model.SessionState_Open.
+func WithSessionState(state model.SessionState) Option {
+	return func(me *MatchingEngine) {
+		me.session = state
+		me.auction = state == model.SessionState_PreOpen
+	}
+}
+
+// WithPriceBand keeps orders from trading further than band.Percent away from
+// the last trade price. Orders that would are rejected or truncated depending
+// on band.Mode.
+func WithPriceBand(band model.PriceBand) Option {
+	return func(me *MatchingEngine) {
+		me.band = band
+	}
+}
+
+// WithCircuitBreaker halts the engine when prices move more than cb.Percent
+// within cb.Window. Trading reopens with the first command after cb.CoolingOff,
+// or earlier through SetSessionState.
+func WithCircuitBreaker(cb model.CircuitBreaker) Option {
+	return func(me *MatchingEngine) {
+		me.breaker = newCircuitBreaker(cb)
+	}
+}
+
+// WithAccounts checks orders against the balances their owners hold in
+// accounts, where the instrument trades base for quote. Funds are locked while
+// an order works, released when it is cancelled and exchanged when it trades.
+// Stop orders are checked once they are triggered. Balances live outside the
+// engine and are not part of its snapshots or journal.
+func WithAccounts(accounts *account.Accounts, base, quote string) Option {
+	return func(me *MatchingEngine) {
+		me.accounts = accounts
+		me.baseAsset = base
+		me.quoteAsset = quote
+	}
+}
+
+// WithFeeSchedule charges maker and taker fees on every trade as set by
+// schedule. Fees are taken from what each side receives when settled with
+// WithAccounts, which also names the assets they are paid in.
+func WithFeeSchedule(schedule model.FeeSchedule) Option {
+	return func(me *MatchingEngine) {
+		me.fees = newFeeLedger(schedule)
+	}
+}
+
+// WithAllocator sets how fills are shared among the orders resting at one
+// price, see orderbook.Allocator. Orders are filled in time priority by
+// default.
+func WithAllocator(allocator orderbook.Allocator) Option {
+	return func(me *MatchingEngine) {
+		me.allocator = allocator
+	}
+}
@@ -0,0 +1,176 @@
+package matchingenginecore
+
+import (
+	"github.com/dylantkx/matching-engine-core/model"
+	"github.com/shopspring/decimal"
+)
+
+// OrderState is what the engine knows about a live order in order to send
+// execution reports for it. It is dropped once the order is done, and kept in
+// EngineSnapshot.States while the order is live.
+type OrderState struct {
+	OrderID     string          `json:"orderId"`
+	OwnerID     string          `json:"ownerId,omitempty"`
+	Side        model.OrderSide `json:"side"`
+	Price       decimal.Decimal `json:"price"`
+	CumUnits    decimal.Decimal `json:"cumUnits"`
+	CumQuote    decimal.Decimal `json:"cumQuote"`
+	LeavesUnits decimal.Decimal `json:"leavesUnits"`
+	LeavesQuote decimal.Decimal `json:"leavesQuote"`
+	// Locked is what the order holds of its owner's funds, see WithAccounts.
+	Locked decimal.Decimal `json:"locked"`
+}
+
+func (s *OrderState) liveStatus() model.OrdStatus {
+	if s.CumUnits.IsPositive() {
+		return model.OrdStatus_PartiallyFilled
+	}
+	return model.OrdStatus_New
+}
+
+func (s *OrderState) isDone() bool {
+	return !s.LeavesUnits.IsPositive() && !s.LeavesQuote.IsPositive()
+}
+
+func (s *OrderState) avgPrice() decimal.Decimal {
+	if !s.CumUnits.IsPositive() {
+		return decimal.Zero
+	}
+	return s.CumQuote.Div(s.CumUnits)
+}
+
+// trackOrder returns the state of an order, starting a new one if the order is
+// not live yet.
+func (me *MatchingEngine) trackOrder(id, ownerID string, side model.OrderSide, price, units decimal.Decimal) (s *OrderState, isNew bool) {
+	if s = me.states[id]; s != nil {
+		return s, false
+	}
+	s = &OrderState{
+		OrderID:     id,
+		OwnerID:     ownerID,
+		Side:        side,
+		Price:       price,
+		LeavesUnits: units,
+	}
+	me.states[id] = s
+	return s, true
+}
+
+func (me *MatchingEngine) report(r *model.MatchResult, s *OrderState, execType model.ExecType, status model.OrdStatus, reason string) {
+	me.addReport(r, me.newReport(s, execType, status, reason))
+}
+
+func (me *MatchingEngine) newReport(s *OrderState, execType model.ExecType, status model.OrdStatus, reason string) model.ExecutionReport {
+	return model.ExecutionReport{
+		Symbol:      me.symbol,
+		OrderID:     s.OrderID,
+		OwnerID:     s.OwnerID,
+		Side:        s.Side,
+		ExecType:    execType,
+		OrdStatus:   status,
+		Price:       s.Price,
+		CumUnits:    s.CumUnits,
+		LeavesUnits: s.LeavesUnits,
+		LeavesQuote: s.LeavesQuote,
+		AvgPrice:    s.avgPrice(),
+		Reason:      reason,
+		EventTime:   model.Timestamp{Time: me.now},
+	}
+}
+
+func (me *MatchingEngine) addReport(r *model.MatchResult, rep model.ExecutionReport) {
+	r.Reports = append(r.Reports, rep)
+	me.publish(model.Event{Type: model.EventType_Report, Report: &rep})
+}
+
+func (me *MatchingEngine) reportNew(r *model.MatchResult, s *OrderState) {
+	me.report(r, s, model.ExecType_New, model.OrdStatus_New, "")
+}
+
+// addTrade adds a trade to r along with a report for the taker and the maker.
+func (me *MatchingEngine) addTrade(r *model.MatchResult, tr model.Trade) {
+	me.chargeFees(&tr)
+	r.Trades = append(r.Trades, tr)
+	me.publish(model.Event{Type: model.EventType_Trade, Trade: &tr})
+	me.settleTrade(tr)
+	me.ticker.addTrade(tr)
+	if me.candles != nil {
+		me.candles.AddTrade(tr)
+	}
+	ids := []string{tr.BuyOrderID, tr.SellOrderID}
+	if tr.IsBuyerMaker {
+		ids[0], ids[1] = ids[1], ids[0]
+	}
+	for _, id := range ids {
+		s := me.states[id]
+		if s == nil {
+			continue
+		}
+		s.CumUnits = s.CumUnits.Add(tr.Units)
+		s.CumQuote = s.CumQuote.Add(tr.Units.Mul(tr.Price))
+		s.LeavesUnits = decimal.Max(s.LeavesUnits.Sub(tr.Units), decimal.Zero)
+		s.LeavesQuote = decimal.Max(s.LeavesQuote.Sub(tr.Units.Mul(tr.Price)), decimal.Zero)
+		status := s.liveStatus()
+		if s.isDone() {
+			status = model.OrdStatus_Filled
+			me.releaseFunds(s, decimal.Zero)
+			delete(me.states, id)
+		} else {
+			me.releaseExcess(s)
+		}
+		rep := me.newReport(s, model.ExecType_Trade, status, "")
+		rep.LastUnits = tr.Units
+		rep.LastPrice = tr.Price
+		if tr.Fees != nil {
+			fee := tr.Fees.Seller
+			if id == tr.BuyOrderID {
+				fee = tr.Fees.Buyer
+			}
+			rep.Fee = &fee
+		}
+		me.addReport(r, rep)
+	}
+}
+
+// addCancellation adds a cancellation to r along with a report for the
+// order. A cancellation that leaves units open restates the order.
+func (me *MatchingEngine) addCancellation(r *model.MatchResult, c model.OrderCancellation) {
+	r.Cancellations = append(r.Cancellations, c)
+	me.publish(model.Event{Type: model.EventType_Cancellation, Cancellation: &c})
+	s := me.states[c.OrderID]
+	if s == nil {
+		return
+	}
+	s.LeavesUnits = decimal.Max(s.LeavesUnits.Sub(c.Units), decimal.Zero)
+	s.LeavesQuote = decimal.Max(s.LeavesQuote.Sub(c.QuoteAmount), decimal.Zero)
+	if s.isDone() {
+		me.releaseFunds(s, decimal.Zero)
+	} else {
+		me.releaseExcess(s)
+	}
+	switch {
+	case !s.isDone():
+		me.report(r, s, model.ExecType_Restated, s.liveStatus(), c.Reason)
+	case c.Reason == model.CancelReason_Expired:
+		delete(me.states, c.OrderID)
+		me.report(r, s, model.ExecType_Expired, model.OrdStatus_Expired, c.Reason)
+	default:
+		delete(me.states, c.OrderID)
+		me.report(r, s, model.ExecType_Canceled, model.OrdStatus_Canceled, c.Reason)
+	}
+}
+
+// addRejection adds a rejection to r along with a report for the order.
+func (me *MatchingEngine) addRejection(r *model.MatchResult, rej model.OrderRejection) {
+	r.Rejections = append(r.Rejections, rej)
+	me.publish(model.Event{Type: model.EventType_Rejection, Rejection: &rej})
+	s := me.states[rej.OrderID]
+	if s == nil {
+		return
+	}
+	delete(me.states, rej.OrderID)
+	me.releaseFunds(s, decimal.Zero)
+	s.LeavesUnits = decimal.Zero
+	s.LeavesQuote = decimal.Zero
+	me.report(r, s, model.ExecType_Rejected, model.OrdStatus_Rejected, rej.Reason)
+}
@@ -0,0 +1,94 @@
+package matchingenginecore_test
+
+import (
+	"testing"
+
+	me "github.com/dylantkx/matching-engine-core"
+	"github.com/dylantkx/matching-engine-core/model"
+	"github.com/shopspring/decimal"
+)
+
+func TestExecutionReports(t *testing.T) {
+	engine := me.NewMatchingEngine()
+
+	r := engine.ProcessLimitOrder(&model.OrderLimit{
+		ID:    "1",
+		Units: decimal.NewFromFloat(2),
+		Price: decimal.NewFromFloat(100),
+		Side:  model.OrderSide_Sell,
+	})
+	if len(r.Reports) != 1 || r.Reports[0].ExecType != model.ExecType_New || r.Reports[0].OrdStatus != model.OrdStatus_New {
+		t.Fatalf("expect a new report, but got %+v", r.Reports)
+	}
+
+	r = engine.ProcessMarketOrder(&model.OrderMarket{
+		ID:    "2",
+		Units: decimal.NewFromFloat(3),
+		Side:  model.OrderSide_Buy,
+	})
+	want := []struct {
+		id       string
+		execType model.ExecType
+		status   model.OrdStatus
+		cum      float64
+		leaves   float64
+	}{
+		{"2", model.ExecType_New, model.OrdStatus_New, 0, 3},
+		{"2", model.ExecType_Trade, model.OrdStatus_PartiallyFilled, 2, 1},
+		{"1", model.ExecType_Trade, model.OrdStatus_Filled, 2, 0},
+		{"2", model.ExecType_Canceled, model.OrdStatus_Canceled, 2, 0},
+	}
+	if len(r.Reports) != len(want) {
+		t.Fatalf("expect %d reports but got %+v", len(want), r.Reports)
+	}
+	for i, w := range want {
+		rep := r.Reports[i]
+		if rep.OrderID != w.id || rep.ExecType != w.execType || rep.OrdStatus != w.status ||
+			!rep.CumUnits.Equal(decimal.NewFromFloat(w.cum)) || !rep.LeavesUnits.Equal(decimal.NewFromFloat(w.leaves)) {
+			t.Fatalf("wrong report %d: %+v", i, rep)
+		}
+	}
+	if !r.Reports[1].LastUnits.Equal(decimal.NewFromFloat(2)) || !r.Reports[1].AvgPrice.Equal(decimal.NewFromFloat(100)) {
+		t.Fatalf("wrong trade report: %+v", r.Reports[1])
+	}
+}
+
+func TestExecutionReportsForCancelAndAmend(t *testing.T) {
+	engine := me.NewMatchingEngine()
+
+	engine.ProcessLimitOrder(&model.OrderLimit{
+		ID:    "1",
+		Units: decimal.NewFromFloat(2),
+		Price: decimal.NewFromFloat(100),
+		Side:  model.OrderSide_Buy,
+	})
+	engine.ProcessMarketOrder(&model.OrderMarket{
+		ID:    "2",
+		Units: decimal.NewFromFloat(0.5),
+		Side:  model.OrderSide_Sell,
+	})
+
+	r, _, err := engine.AmendOrder(&model.OrderAmend{
+		OrderID: "1",
+		Units:   decimal.NewFromFloat(1),
+	})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if len(r.Reports) != 1 || r.Reports[0].ExecType != model.ExecType_Replaced || r.Reports[0].OrdStatus != model.OrdStatus_PartiallyFilled {
+		t.Fatalf("expect a replaced report, but got %+v", r.Reports)
+	}
+
+	r, err = engine.CancelOrderByID("1")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if len(r.Reports) != 1 {
+		t.Fatalf("expect 1 report but got %d", len(r.Reports))
+	}
+	rep := r.Reports[0]
+	if rep.ExecType != model.ExecType_Canceled || rep.OrdStatus != model.OrdStatus_Canceled ||
+		!rep.CumUnits.Equal(decimal.NewFromFloat(0.5)) || !rep.LeavesUnits.IsZero() || rep.Reason != model.CancelReason_Requested {
+		t.Fatalf("wrong cancel report: %+v", rep)
+	}
+}
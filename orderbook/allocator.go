@@ -0,0 +1,100 @@
+package orderbook
+
+import (
+	"math/bits"
+
+	"github.com/shopspring/decimal"
+)
+
+// Allocator decides how an incoming order is shared among the orders resting
+// at one price level. sizes holds the displayed lots of those orders in time
+// priority, and Allocate returns the lots each of them fills, never more than
+// its size and no more than lots in total.
+type Allocator interface {
+	Allocate(sizes []int64, lots int64, scales Scales) []int64
+}
+
+// FIFOAllocator fills resting orders one after the other in time priority.
+// It is what a book without an allocator does.
+type FIFOAllocator struct{}
+
+func (FIFOAllocator) Allocate(sizes []int64, lots int64, _ Scales) []int64 {
+	fills := make([]int64, len(sizes))
+	fillFIFO(fills, sizes, lots)
+	return fills
+}
+
+// ProRataAllocator shares an incoming order among resting orders in
+// proportion to their size. Each share is rounded down to LotSize, shares
+// below MinAllocation are dropped, and what is left over is filled in time
+// priority.
+type ProRataAllocator struct {
+	LotSize       decimal.Decimal
+	MinAllocation decimal.Decimal
+}
+
+func (a ProRataAllocator) Allocate(sizes []int64, lots int64, scales Scales) []int64 {
+	fills := make([]int64, len(sizes))
+	var total int64
+	for _, size := range sizes {
+		total += size
+	}
+	if lots >= total {
+		copy(fills, sizes)
+		return fills
+	}
+	lot := scales.toLots(a.LotSize)
+	if lot < 1 {
+		lot = 1
+	}
+	minLots := scales.toLots(a.MinAllocation)
+	left := lots
+	for i, size := range sizes {
+		// lots < total, so the share is below size and the division can not
+		// overflow
+		hi, lo := bits.Mul64(uint64(lots), uint64(size))
+		q, _ := bits.Div64(hi, lo, uint64(total))
+		share := int64(q)
+		share -= share % lot
+		if share < minLots {
+			share = 0
+		}
+		fills[i] = share
+		left -= share
+	}
+	fillFIFO(fills, sizes, left)
+	return fills
+}
+
+// HybridAllocator first fills the order at the front of the queue with up to
+// TopOrderPercent of the incoming order, then shares the rest pro-rata.
+type HybridAllocator struct {
+	TopOrderPercent decimal.Decimal
+	ProRata         ProRataAllocator
+}
+
+func (a HybridAllocator) Allocate(sizes []int64, lots int64, scales Scales) []int64 {
+	if len(sizes) == 0 {
+		return nil
+	}
+	top := decimal.NewFromInt(lots).Mul(a.TopOrderPercent).Div(decimal.NewFromInt(100)).IntPart()
+	if lot := scales.toLots(a.ProRata.LotSize); lot > 1 {
+		top -= top % lot
+	}
+	top = min64(min64(top, sizes[0]), lots)
+	rest := append([]int64(nil), sizes...)
+	rest[0] -= top
+	fills := a.ProRata.Allocate(rest, lots-top, scales)
+	fills[0] += top
+	return fills
+}
+
+// fillFIFO adds up to lots to fills in time priority, keeping every order
+// within its size.
+func fillFIFO(fills, sizes []int64, lots int64) {
+	for i := 0; i < len(sizes) && lots > 0; i++ {
+		add := min64(sizes[i]-fills[i], lots)
+		fills[i] += add
+		lots -= add
+	}
+}
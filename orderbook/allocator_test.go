@@ -0,0 +1,59 @@
+package orderbook_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/dylantkx/matching-engine-core/model"
+	"github.com/dylantkx/matching-engine-core/orderbook"
+	"github.com/shopspring/decimal"
+)
+
+func TestAllocators(t *testing.T) {
+	units := orderbook.Scales{}
+	tests := []struct {
+		name      string
+		allocator orderbook.Allocator
+		lots      int64
+		fills     []int64
+	}{
+		{"fifo", orderbook.FIFOAllocator{}, 50, []int64{10, 30, 10}},
+		{"pro-rata", orderbook.ProRataAllocator{}, 50, []int64{5, 15, 30}},
+		{"pro-rata sweep", orderbook.ProRataAllocator{}, 200, []int64{10, 30, 60}},
+		{"pro-rata lot size", orderbook.ProRataAllocator{LotSize: decimal.NewFromInt(4)}, 50, []int64{10, 12, 28}},
+		{"pro-rata min allocation", orderbook.ProRataAllocator{MinAllocation: decimal.NewFromInt(5)}, 10, []int64{4, 0, 6}},
+		{"hybrid", orderbook.HybridAllocator{TopOrderPercent: decimal.NewFromInt(40)}, 50, []int64{10, 14, 26}},
+	}
+
+	for _, tt := range tests {
+		fills := tt.allocator.Allocate([]int64{10, 30, 60}, tt.lots, units)
+		if fmt.Sprint(fills) != fmt.Sprint(tt.fills) {
+			t.Fatalf("%s: expect fills %v but got %v", tt.name, tt.fills, fills)
+		}
+	}
+}
+
+func TestBookWithProRataAllocator(t *testing.T) {
+	b := orderbook.NewBook(orderbook.WithAllocator(orderbook.ProRataAllocator{
+		LotSize: decimal.NewFromFloat(0.1),
+	}))
+	b.AddSellOrder(model.Order{ID: "1", Units: decimal.NewFromFloat(1), Price: decimal.NewFromFloat(100)})
+	b.AddSellOrder(model.Order{ID: "2", Units: decimal.NewFromFloat(3), Price: decimal.NewFromFloat(100)})
+	b.AddSellOrder(model.Order{ID: "3", Units: decimal.NewFromFloat(2), Price: decimal.NewFromFloat(101)})
+
+	cleared := b.ClearSellSideByUnits(decimal.NewFromFloat(2))
+	expected := map[string]float64{"1": 0.5, "2": 1.5}
+	if len(cleared) != len(expected) {
+		t.Fatalf("expect %d fills but got %d", len(expected), len(cleared))
+	}
+	for _, o := range cleared {
+		if !o.Units.Equal(decimal.NewFromFloat(expected[o.ID])) {
+			t.Fatalf("expect order %s to fill %v but got %s", o.ID, expected[o.ID], o.Units)
+		}
+	}
+
+	cleared = b.ClearSellSideByUnits(decimal.NewFromFloat(3))
+	if len(cleared) != 3 || cleared[2].ID != "3" || !cleared[2].Units.Equal(decimal.NewFromFloat(1)) {
+		t.Fatalf("expect the level to be swept before the next one but got %+v", cleared)
+	}
+}
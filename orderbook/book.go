@@ -1,7 +1,9 @@
 package orderbook
 
 import (
-	"errors"
+	"fmt"
+	"math"
+	"sort"
 	"sync"
 
 	"github.com/dylantkx/matching-engine-core/model"
@@ -15,44 +17,131 @@ const (
 )
 
 type Book interface {
-	AddBuyOrder(order model.Order)
-	AddSellOrder(order model.Order)
+	AddBuyOrder(order model.Order) error
+	AddSellOrder(order model.Order) error
 	CancelOrder(order model.Order) ([]model.OrderCancellation, error)
+	CancelOrderByID(id string) ([]model.OrderCancellation, error)
+	DecreaseOrder(id string, units decimal.Decimal) error
+	GetOrder(id string) (model.Order, bool)
 	ClearBuySideByUnitsAndPrice(units decimal.Decimal, price decimal.Decimal) (clearedOrders []*model.Order)
 	ClearSellSideByUnitsAndPrice(units decimal.Decimal, price decimal.Decimal) (clearedOrders []*model.Order)
 	ClearBuySideByUnits(units decimal.Decimal) (clearedOrders []*model.Order)
 	ClearSellSideByUnits(units decimal.Decimal) (clearedOrders []*model.Order)
+	ClearBuySide(units decimal.Decimal, opts ClearOptions) ClearResult
+	ClearSellSide(units decimal.Decimal, opts ClearOptions) ClearResult
+	ClearSellSideByQuote(quote decimal.Decimal, opts ClearOptions) ClearResult
 	GetFullSnapshot() *BookSnapshot
 	GetSnapshotWithDepth(depth int) *BookSnapshot
+	GetL3Snapshot() *L3Snapshot
+	GetTotalSnapshot() *BookSnapshot
+	SetDepthListener(fn func(model.DepthUpdate))
 	GetTotalBuyUnitsFromPrice(price decimal.Decimal) decimal.Decimal
 	GetTotalSellUnitsToPrice(price decimal.Decimal) decimal.Decimal
+	GetBuyUnitsFromPriceWithHidden(price decimal.Decimal) decimal.Decimal
+	GetSellUnitsToPriceWithHidden(price decimal.Decimal) decimal.Decimal
+	GetSellSideCost(units decimal.Decimal, opts ClearOptions) decimal.Decimal
+	GetBuySideFillableUnits(units decimal.Decimal, opts ClearOptions) decimal.Decimal
+	GetSellSideFillableUnits(units decimal.Decimal, opts ClearOptions) decimal.Decimal
 	GetHighestBuy() *bookLimit
 	GetLowestSell() *bookLimit
+	GetLowestBuy() *bookLimit
+	GetHighestSell() *bookLimit
+	GetScales() Scales
 }
 
+// book keeps prices as ticks and units as lots, see Scales. Decimals are only
+// converted when orders come in and when results go out.
 type book struct {
+	scales    Scales
+	allocator Allocator
+
 	buyTree     *limitTree
-	buyLimitMap map[string]*bookLimit
+	buyLimitMap map[int64]*bookLimit
 	highestBuy  *bookLimit
 	buyMu       sync.RWMutex
 
 	sellTree     *limitTree
-	sellLimitMap map[string]*bookLimit
+	sellLimitMap map[int64]*bookLimit
 	lowestSell   *bookLimit
 	sellMu       sync.RWMutex
+
+	orderIndex map[string]*bookOrder
+	indexMu    sync.RWMutex
+
+	seq           uint64
+	depthListener func(model.DepthUpdate)
+	depthMu       sync.Mutex
+}
+
+type Option func(b *book)
+
+// WithScales sets the decimal places prices and units are kept with. It
+// defaults to DefaultScales.
+func WithScales(scales Scales) Option {
+	return func(b *book) {
+		b.scales = scales
+	}
+}
+
+// WithAllocator sets how fills are shared among the orders resting at a
+// level. Orders are filled in time priority by default.
+func WithAllocator(allocator Allocator) Option {
+	return func(b *book) {
+		b.allocator = allocator
+	}
 }
 
-func NewBook() *book {
-	return &book{
+func NewBook(opts ...Option) *book {
+	b := &book{
+		scales: DefaultScales,
 		buyTree: btree.NewWithFreeListG(treeDegree, func(a, b limitTreeNode) bool {
-			return a.Price.LessThan(b.Price)
+			return a.Ticks < b.Ticks
 		}, btree.NewFreeListG[limitTreeNode](maxOrderPerLimit)),
-		buyLimitMap: make(map[string]*bookLimit),
+		buyLimitMap: make(map[int64]*bookLimit),
 		sellTree: btree.NewWithFreeListG(treeDegree, func(a, b limitTreeNode) bool {
-			return a.Price.LessThan(b.Price)
+			return a.Ticks < b.Ticks
 		}, btree.NewFreeListG[limitTreeNode](maxOrderPerLimit)),
-		sellLimitMap: make(map[string]*bookLimit),
+		sellLimitMap: make(map[int64]*bookLimit),
+		orderIndex:   make(map[string]*bookOrder),
+	}
+	for _, opt := range opts {
+		opt(b)
 	}
+	return b
+}
+
+// NewBookFromSnapshot rebuilds the book captured by sn, with every order back
+// at its queue position. Depth updates carry on from the sequence of sn.
+func NewBookFromSnapshot(sn *L3Snapshot, opts ...Option) (*book, error) {
+	b := NewBook(opts...)
+	seen := make(map[string]bool)
+	for _, records := range [][]*l3SnapshotRecord{sn.Buys, sn.Sells} {
+		records = append([]*l3SnapshotRecord(nil), records...)
+		sort.SliceStable(records, func(i, j int) bool {
+			return records[i].Position < records[j].Position
+		})
+		for _, rec := range records {
+			if seen[rec.ID] || !rec.Units.IsPositive() || !rec.Price.IsPositive() {
+				return nil, fmt.Errorf("%w: order %s", ErrInvalidSnapshot, rec.ID)
+			}
+			seen[rec.ID] = true
+			var err error
+			if rec.Side == model.OrderSide_Buy {
+				err = b.AddBuyOrder(rec.order())
+			} else {
+				err = b.AddSellOrder(rec.order())
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s", ErrInvalidSnapshot, err)
+			}
+		}
+	}
+	b.seq = sn.Sequence
+	return b, nil
+}
+
+func (b *book) GetScales() Scales {
+	return b.scales
 }
 
 func (b *book) GetHighestBuy() *bookLimit {
@@ -67,288 +156,690 @@ func (b *book) GetLowestSell() *bookLimit {
 	return b.lowestSell
 }
 
+// GetLowestBuy returns the worst priced buy level, the last one a market sell
+// order would reach.
+func (b *book) GetLowestBuy() *bookLimit {
+	b.buyMu.RLock()
+	defer b.buyMu.RUnlock()
+	n, _ := b.buyTree.Min()
+	return n.LimitRef
+}
+
+// GetHighestSell returns the worst priced sell level, the last one a market
+// buy order would reach.
+func (b *book) GetHighestSell() *bookLimit {
+	b.sellMu.RLock()
+	defer b.sellMu.RUnlock()
+	n, _ := b.sellTree.Max()
+	return n.LimitRef
+}
+
 func (b *book) GetTotalBuyUnitsFromPrice(price decimal.Decimal) decimal.Decimal {
 	b.buyMu.RLock()
 	defer b.buyMu.RUnlock()
-	sum := decimal.Zero
+	ticks := b.scales.ceilTicks(price)
+	var sum int64
 	b.buyTree.Descend(func(item limitTreeNode) bool {
-		if item.LimitRef == nil || item.LimitRef.Price.LessThan(price) {
+		if item.LimitRef == nil || item.Ticks < ticks {
 			return false
 		}
-		sum = sum.Add(item.LimitRef.Size)
+		sum += item.LimitRef.lots
 		return true
 	})
-	return sum
+	return b.scales.fromLots(sum)
 }
 
 func (b *book) GetTotalSellUnitsToPrice(price decimal.Decimal) decimal.Decimal {
 	b.sellMu.RLock()
 	defer b.sellMu.RUnlock()
-	sum := decimal.Zero
+	ticks := b.scales.floorTicks(price)
+	var sum int64
 	b.sellTree.Ascend(func(item limitTreeNode) bool {
-		if item.LimitRef == nil || item.LimitRef.Price.GreaterThan(price) {
+		if item.LimitRef == nil || item.Ticks > ticks {
 			return false
 		}
-		sum = sum.Add(item.LimitRef.Size)
+		sum += item.LimitRef.lots
 		return true
 	})
-	return sum
+	return b.scales.fromLots(sum)
 }
 
-func (b *book) AddBuyOrder(order model.Order) {
-	b.buyMu.Lock()
-	defer b.buyMu.Unlock()
-	bl := b.buyLimitMap[order.Price.String()]
-	if bl == nil {
-		bl = NewBookLimit()
-		b.buyLimitMap[order.Price.String()] = bl
+// GetBuyUnitsFromPriceWithHidden is GetTotalBuyUnitsFromPrice with the hidden
+// units of iceberg orders counted, which is what clearing down to price can
+// fill.
+func (b *book) GetBuyUnitsFromPriceWithHidden(price decimal.Decimal) decimal.Decimal {
+	b.buyMu.RLock()
+	defer b.buyMu.RUnlock()
+	ticks := b.scales.ceilTicks(price)
+	var sum int64
+	b.buyTree.Descend(func(item limitTreeNode) bool {
+		if item.LimitRef == nil || item.Ticks < ticks {
+			return false
+		}
+		sum += item.LimitRef.totalLots()
+		return true
+	})
+	return b.scales.fromLots(sum)
+}
+
+// GetSellUnitsToPriceWithHidden is GetTotalSellUnitsToPrice with the hidden
+// units of iceberg orders counted, which is what clearing up to price can
+// fill.
+func (b *book) GetSellUnitsToPriceWithHidden(price decimal.Decimal) decimal.Decimal {
+	b.sellMu.RLock()
+	defer b.sellMu.RUnlock()
+	ticks := b.scales.floorTicks(price)
+	var sum int64
+	b.sellTree.Ascend(func(item limitTreeNode) bool {
+		if item.LimitRef == nil || item.Ticks > ticks {
+			return false
+		}
+		sum += item.LimitRef.totalLots()
+		return true
+	})
+	return b.scales.fromLots(sum)
+}
+
+// GetSellSideCost returns what buying up to units from the sell side would
+// cost, best price first and never beyond opts.Price, hidden units included.
+// Orders of the same owner are left out when self-trade prevention is on, so
+// the cost is an upper bound whatever the prevention mode.
+func (b *book) GetSellSideCost(units decimal.Decimal, opts ClearOptions) decimal.Decimal {
+	b.sellMu.RLock()
+	defer b.sellMu.RUnlock()
+	limit := int64(math.MaxInt64)
+	if opts.Price != nil {
+		limit = b.scales.floorTicks(*opts.Price)
 	}
-	if isUpdate := bl.InsertOrUpdateOrder(&order); !isUpdate {
-		n := limitTreeNode{
-			Price:    order.Price,
-			LimitRef: bl,
+	lots := b.scales.toLots(units)
+	cost := decimal.Zero
+	b.sellTree.Ascend(func(item limitTreeNode) bool {
+		if item.LimitRef == nil || item.Ticks > limit {
+			return false
 		}
-		b.buyTree.ReplaceOrInsert(n)
-		if b.highestBuy == nil || b.highestBuy.Price.LessThan(bl.Price) {
-			b.highestBuy = bl
+		bl := item.LimitRef
+		bl.mu.RLock()
+		defer bl.mu.RUnlock()
+		for o := bl.firstBookOrder; o != nil && lots > 0; o = o.nextBookOrder {
+			if opts.preventsSelfTrade(o.OwnerID) {
+				continue
+			}
+			take := min64(o.totalLots(), lots)
+			cost = cost.Add(b.scales.fromLots(take).Mul(o.Price))
+			lots -= take
 		}
+		return lots > 0
+	})
+	return cost
+}
+
+// GetBuySideFillableUnits returns how many of units clearing the buy side with
+// opts would fill, hidden units included, before self-trade prevention
+// cancels any of the incoming order. Orders of the same owner are skipped
+// with SelfTradePrevention_CancelOldest; with any other mode clearing is only
+// counted up to the first one reached.
+func (b *book) GetBuySideFillableUnits(units decimal.Decimal, opts ClearOptions) decimal.Decimal {
+	b.buyMu.RLock()
+	defer b.buyMu.RUnlock()
+	limit := int64(math.MinInt64)
+	if opts.Price != nil {
+		limit = b.scales.ceilTicks(*opts.Price)
 	}
+	want := b.scales.toLots(units)
+	lots := want
+	b.buyTree.Descend(func(item limitTreeNode) bool {
+		if item.LimitRef == nil || item.Ticks < limit {
+			return false
+		}
+		var blocked bool
+		lots, blocked = b.fillableLots(item.LimitRef, lots, &opts)
+		return lots > 0 && !blocked
+	})
+	return b.scales.fromLots(want - lots)
 }
 
-func (b *book) AddSellOrder(order model.Order) {
-	b.sellMu.Lock()
-	defer b.sellMu.Unlock()
-	bl := b.sellLimitMap[order.Price.String()]
+// GetSellSideFillableUnits is GetBuySideFillableUnits for the sell side.
+func (b *book) GetSellSideFillableUnits(units decimal.Decimal, opts ClearOptions) decimal.Decimal {
+	b.sellMu.RLock()
+	defer b.sellMu.RUnlock()
+	limit := int64(math.MaxInt64)
+	if opts.Price != nil {
+		limit = b.scales.floorTicks(*opts.Price)
+	}
+	want := b.scales.toLots(units)
+	lots := want
+	b.sellTree.Ascend(func(item limitTreeNode) bool {
+		if item.LimitRef == nil || item.Ticks > limit {
+			return false
+		}
+		var blocked bool
+		lots, blocked = b.fillableLots(item.LimitRef, lots, &opts)
+		return lots > 0 && !blocked
+	})
+	return b.scales.fromLots(want - lots)
+}
+
+// fillableLots takes from lots what clearing bl would fill, and tells if an
+// order of the same owner stops the incoming order at bl. Orders queued ahead
+// of that order fill once, as iceberg slices shown again queue behind it, and
+// with an allocator it is reached before anything at the level fills.
+func (b *book) fillableLots(bl *bookLimit, lots int64, opts *ClearOptions) (int64, bool) {
+	bl.mu.RLock()
+	defer bl.mu.RUnlock()
+	if opts.SelfTradePrevention == "" || opts.OwnerID == "" {
+		return lots - min64(bl.lots+bl.hiddenLots, lots), false
+	}
+	var ahead, total int64
+	for o := bl.firstBookOrder; o != nil; o = o.nextBookOrder {
+		if !opts.preventsSelfTrade(o.OwnerID) {
+			ahead += o.lots
+			total += o.totalLots()
+			continue
+		}
+		if opts.SelfTradePrevention == model.SelfTradePrevention_CancelOldest {
+			continue
+		}
+		if b.allocator != nil {
+			ahead = 0
+		}
+		return lots - min64(ahead, lots), true
+	}
+	return lots - min64(total, lots), false
+}
+
+// AddBuyOrder rests order on the buy side. Re-adding an order ID that rests at
+// the same price replaces it in place, keeping its queue position; one that
+// rests anywhere else is removed from there first. An order whose price or
+// units are finer than the book's scales cannot be kept without rounding, so
+// it is not added and ErrPrecisionExceeded is returned.
+func (b *book) AddBuyOrder(order model.Order) error {
+	return b.addOrder(model.OrderSide_Buy, &order)
+}
+
+// AddSellOrder rests order on the sell side, with the same replacement rules
+// as AddBuyOrder.
+func (b *book) AddSellOrder(order model.Order) error {
+	return b.addOrder(model.OrderSide_Sell, &order)
+}
+
+func (b *book) addOrder(side model.OrderSide, order *model.Order) error {
+	if !b.scales.canRepresentOrder(order) {
+		return &PrecisionExceededError{OrderID: order.ID}
+	}
+	o := newBookOrder(order, b.scales)
+	o.Side = side
+	splitIceberg(o)
+	b.removeStaleOrder(o)
+
+	t, m, mu := b.side(side)
+	mu.Lock()
+	defer mu.Unlock()
+	bl := m[o.ticks]
 	if bl == nil {
-		bl = NewBookLimit()
-		b.sellLimitMap[order.Price.String()] = bl
+		bl = newBookLimit(o.Price, b.scales)
+		m[o.ticks] = bl
 	}
-	if isUpdate := bl.InsertOrUpdateOrder(&order); !isUpdate {
-		n := limitTreeNode{
-			Price:    order.Price,
+	queued, isUpdate := bl.insertOrUpdate(o)
+	b.indexOrder(queued)
+	if !isUpdate {
+		t.ReplaceOrInsert(limitTreeNode{
+			Ticks:    o.ticks,
 			LimitRef: bl,
+		})
+		if side == model.OrderSide_Buy && (b.highestBuy == nil || b.highestBuy.ticks < bl.ticks) {
+			b.highestBuy = bl
 		}
-		b.sellTree.ReplaceOrInsert(n)
-		if b.lowestSell == nil || b.lowestSell.Price.GreaterThan(bl.Price) {
+		if side == model.OrderSide_Sell && (b.lowestSell == nil || b.lowestSell.ticks > bl.ticks) {
 			b.lowestSell = bl
 		}
 	}
+	b.publishDepth(side, bl)
+	return nil
 }
 
-func (b *book) CancelOrder(order model.Order) (cancels []model.OrderCancellation, err error) {
-	var t *limitTree
-	var m map[string]*bookLimit
-	var best *bookLimit
-	var mu *sync.RWMutex
-	if order.Side == model.OrderSide_Buy {
-		t = b.buyTree
-		m = b.buyLimitMap
-		best = b.highestBuy
-		mu = &b.buyMu
-	} else {
-		t = b.sellTree
-		m = b.sellLimitMap
-		best = b.lowestSell
-		mu = &b.sellMu
+func (b *book) GetOrder(id string) (model.Order, bool) {
+	b.indexMu.RLock()
+	defer b.indexMu.RUnlock()
+	o := b.orderIndex[id]
+	if o == nil {
+		return model.Order{}, false
 	}
-	bl := m[order.Price.String()]
-	if bl == nil {
-		err = errors.New("order not found")
+	return o.toOrder(b.scales), true
+}
+
+func (b *book) CancelOrderByID(id string) ([]model.OrderCancellation, error) {
+	order, ok := b.GetOrder(id)
+	if !ok {
+		return nil, &OrderNotFoundError{OrderID: id}
+	}
+	return b.CancelOrder(order)
+}
+
+func (b *book) CancelOrder(order model.Order) (cancels []model.OrderCancellation, err error) {
+	o := b.cancel(order.Side, b.scales.toTicks(order.Price), order.ID)
+	if o == nil {
+		err = &OrderNotFoundError{OrderID: order.ID}
 		return
 	}
+	cancels = append(cancels, model.OrderCancellation{
+		OrderID: order.ID,
+		Units:   b.scales.fromLots(o.totalLots()),
+		Reason:  model.CancelReason_Requested,
+	})
+	return
+}
+
+// cancel takes the order with id off the level at ticks and returns it, or
+// nil if it does not rest there.
+func (b *book) cancel(side model.OrderSide, ticks int64, id string) *bookOrder {
+	t, m, mu := b.side(side)
 	mu.Lock()
 	defer mu.Unlock()
-	bl.RemoveOrder(&order)
+	bl := m[ticks]
+	if bl == nil {
+		return nil
+	}
+	o := bl.removeOrder(id)
+	if o == nil {
+		return nil
+	}
+	b.unindexOrder(id)
 	if bl.IsEmpty() {
-		delete(m, bl.Price.String())
-		t.Delete(limitTreeNode{Price: bl.Price})
-		if best != nil && best.Price.Equal(bl.Price) {
-			best = nil
+		delete(m, ticks)
+		t.Delete(limitTreeNode{Ticks: ticks})
+		b.resetBest(side)
+	}
+	b.publishDepth(side, bl)
+	return o
+}
+
+// DecreaseOrder shrinks a resting order to units in total while keeping its
+// queue position. Hidden units of an iceberg order are taken off first.
+func (b *book) DecreaseOrder(id string, units decimal.Decimal) error {
+	order, ok := b.GetOrder(id)
+	if !ok {
+		return &OrderNotFoundError{OrderID: id}
+	}
+	if !units.IsPositive() || units.GreaterThanOrEqual(order.GetTotalUnits()) || !b.scales.CanRepresentUnits(units) {
+		return ErrInvalidDecrease
+	}
+	_, m, mu := b.side(order.Side)
+	mu.Lock()
+	defer mu.Unlock()
+	b.indexMu.Lock()
+	defer b.indexMu.Unlock()
+	resting := b.orderIndex[id]
+	bl := m[resting.ticks]
+	bl.decreaseOrder(id, resting.totalLots()-b.scales.toLots(units))
+	b.publishDepth(resting.Side, bl)
+	return nil
+}
+
+func (b *book) ClearBuySideByUnitsAndPrice(units decimal.Decimal, price decimal.Decimal) (clearedOrders []*model.Order) {
+	return b.ClearBuySide(units, ClearOptions{Price: &price}).ClearedOrders
+}
+
+func (b *book) ClearSellSideByUnitsAndPrice(units decimal.Decimal, price decimal.Decimal) (clearedOrders []*model.Order) {
+	return b.ClearSellSide(units, ClearOptions{Price: &price}).ClearedOrders
+}
+
+func (b *book) ClearBuySideByUnits(units decimal.Decimal) (clearedOrders []*model.Order) {
+	return b.ClearBuySide(units, ClearOptions{}).ClearedOrders
+}
+
+func (b *book) ClearSellSideByUnits(units decimal.Decimal) (clearedOrders []*model.Order) {
+	return b.ClearSellSide(units, ClearOptions{}).ClearedOrders
+}
+
+func (b *book) ClearBuySide(units decimal.Decimal, opts ClearOptions) ClearResult {
+	return b.clearSide(model.OrderSide_Buy, units, &opts)
+}
+
+func (b *book) ClearSellSide(units decimal.Decimal, opts ClearOptions) ClearResult {
+	return b.clearSide(model.OrderSide_Sell, units, &opts)
+}
+
+// ClearSellSideByQuote buys from the sell side, best price first, until quote
+// is spent or the next level is out of reach. The units bought at each level
+// are rounded down to opts.LotSize.
+func (b *book) ClearSellSideByQuote(quote decimal.Decimal, opts ClearOptions) (r ClearResult) {
+	lot := b.scales.toLots(opts.LotSize)
+	if lot < 1 {
+		lot = 1
+	}
+	b.walkClear(model.OrderSide_Sell, &opts, &r, func(bl *bookLimit) (bool, bool) {
+		units, _ := quote.QuoRem(bl.Price, b.scales.UnitsScale)
+		lots := b.scales.toLots(units)
+		lots -= lots % lot
+		if lots <= 0 {
+			return false, false
 		}
+		cleared := len(r.ClearedOrders)
+		lots = b.clearLimit(bl, lots, &opts, &r)
+		for _, o := range r.ClearedOrders[cleared:] {
+			quote = quote.Sub(o.Units.Mul(o.Price))
+		}
+		// a level left with orders means the rest of quote can not buy a lot
+		return true, lots > 0 && bl.IsEmpty() && r.takerCancelledLots == 0
+	})
+	return
+}
+
+// clearSide consumes up to units from the given side, best price first, and
+// never beyond opts.Price when one is given.
+func (b *book) clearSide(side model.OrderSide, units decimal.Decimal, opts *ClearOptions) (r ClearResult) {
+	lots := b.scales.toLots(units)
+	if lots <= 0 {
+		return
 	}
-	cancels = append(cancels, model.OrderCancellation{
-		OrderID: order.ID,
-		Units:   order.Units,
+	b.walkClear(side, opts, &r, func(bl *bookLimit) (bool, bool) {
+		lots = b.clearLimit(bl, lots, opts, &r)
+		return true, lots > 0
 	})
 	return
 }
 
-func (b *book) ClearBuySideByUnitsAndPrice(units decimal.Decimal, price decimal.Decimal) (clearedOrders []*model.Order) {
-	b.buyMu.Lock()
-	defer b.buyMu.Unlock()
-	clearedPrice := make([]decimal.Decimal, 0)
-	b.buyTree.Descend(func(item limitTreeNode) bool {
-		if item.LimitRef == nil || item.Price.LessThan(price) {
+// walkClear hands the levels of side to clear, best price first and never
+// beyond opts.Price, until clear asks for no more. clear tells if it touched
+// the level, whose depth is then published. Emptied levels are dropped from
+// the tree and the limit map, and the best pointer of that side is refreshed.
+func (b *book) walkClear(side model.OrderSide, opts *ClearOptions, r *ClearResult, clear func(bl *bookLimit) (touched, more bool)) {
+	t, m, mu := b.side(side)
+	var walk func(btree.ItemIteratorG[limitTreeNode])
+	var outOfRange func(ticks int64) bool
+	if side == model.OrderSide_Buy {
+		walk = t.Descend
+		if opts.Price != nil {
+			limit := b.scales.ceilTicks(*opts.Price)
+			outOfRange = func(ticks int64) bool { return ticks < limit }
+		}
+	} else {
+		walk = t.Ascend
+		if opts.Price != nil {
+			limit := b.scales.floorTicks(*opts.Price)
+			outOfRange = func(ticks int64) bool { return ticks > limit }
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	clearedTicks := make([]int64, 0)
+	touched := make([]*bookLimit, 0)
+	walk(func(item limitTreeNode) bool {
+		if item.LimitRef == nil || (outOfRange != nil && outOfRange(item.Ticks)) {
 			return false
 		}
-		o := item.LimitRef.firstBookOrder
-		for o != nil && units.IsPositive() {
-			order := o.Order.Clone()
-			if o.Order.Units.LessThanOrEqual(units) {
-				clearedOrders = append(clearedOrders, &order)
-				units = units.Sub(o.Order.Units)
-				item.LimitRef.RemoveOrder(o.Order)
-			} else {
-				order.Units = units.Copy()
-				o.Order.Units = o.Order.Units.Sub(units)
-				item.LimitRef.InsertOrUpdateOrder(o.Order)
-				clearedOrders = append(clearedOrders, &order)
-				units = decimal.Zero
-			}
-			o = o.nextBookOrder
+		changed, more := clear(item.LimitRef)
+		if changed {
+			touched = append(touched, item.LimitRef)
 		}
-		if units.IsPositive() {
-			clearedPrice = append(clearedPrice, item.Price)
-			return true
+		if item.LimitRef.IsEmpty() {
+			clearedTicks = append(clearedTicks, item.Ticks)
 		}
-		return false
+		return more
 	})
 	// TODO: optimize these operations
-	for _, p := range clearedPrice {
-		b.buyTree.Delete(limitTreeNode{Price: p})
-		delete(b.buyLimitMap, p.String())
+	for _, ticks := range clearedTicks {
+		t.Delete(limitTreeNode{Ticks: ticks})
+		delete(m, ticks)
 	}
-	if len(clearedPrice) > 0 {
-		n, _ := b.buyTree.Max()
-		b.highestBuy = n.LimitRef
+	if len(clearedTicks) > 0 {
+		b.resetBest(side)
 	}
-	return
+	for _, bl := range touched {
+		b.publishDepth(side, bl)
+	}
+	r.TakerCancelledUnits = b.scales.fromLots(r.takerCancelledLots)
 }
 
-func (b *book) ClearSellSideByUnitsAndPrice(units decimal.Decimal, price decimal.Decimal) (clearedOrders []*model.Order) {
-	b.sellMu.Lock()
-	defer b.sellMu.Unlock()
-	clearedPrice := make([]decimal.Decimal, 0)
-	b.sellTree.Ascend(func(item limitTreeNode) bool {
-		if item.LimitRef == nil || item.Price.GreaterThan(price) {
-			return false
+// clearLimit fills orders of a single level in FIFO order until lots runs
+// out, adding the outcome to r and returning the lots left unfilled. Each
+// displayed slice of an iceberg order is reported as its own fill.
+func (b *book) clearLimit(bl *bookLimit, lots int64, opts *ClearOptions, r *ClearResult) int64 {
+	if b.allocator != nil {
+		return b.allocateLimit(bl, lots, opts, r)
+	}
+	for o := bl.firstBookOrder; o != nil && lots > 0; o = bl.firstBookOrder {
+		if opts.preventsSelfTrade(o.OwnerID) {
+			lots = b.preventSelfTrade(bl, o, lots, opts.SelfTradePrevention, r)
+			continue
 		}
-		o := item.LimitRef.firstBookOrder
-		for o != nil && units.IsPositive() {
-			order := o.Order.Clone()
-			if o.Order.Units.LessThanOrEqual(units) {
-				clearedOrders = append(clearedOrders, &order)
-				units = units.Sub(o.Order.Units)
-				item.LimitRef.RemoveOrder(o.Order)
+		fill := o.lots
+		if fill <= lots {
+			bl.removeOrder(o.ID)
+			if o.hiddenLots > 0 {
+				replenishIceberg(o)
+				bl.insertOrUpdate(o)
 			} else {
-				order.Units = units.Copy()
-				o.Order.Units = o.Order.Units.Sub(units)
-				item.LimitRef.InsertOrUpdateOrder(o.Order)
-				clearedOrders = append(clearedOrders, &order)
-				units = decimal.Zero
+				b.unindexOrder(o.ID)
 			}
-			o = o.nextBookOrder
-		}
-		if units.IsPositive() {
-			clearedPrice = append(clearedPrice, item.Price)
-			return true
+		} else {
+			fill = lots
+			bl.reduceOrder(o.ID, fill)
 		}
-		return false
-	})
-	// TODO: optimize these operations
-	for _, p := range clearedPrice {
-		b.sellTree.Delete(limitTreeNode{Price: p})
-		delete(b.sellLimitMap, p.String())
-	}
-	if len(clearedPrice) > 0 {
-		n, _ := b.sellTree.Min()
-		b.lowestSell = n.LimitRef
+		lots -= fill
+		r.ClearedOrders = append(r.ClearedOrders, &model.Order{
+			ID:      o.ID,
+			OwnerID: o.OwnerID,
+			Units:   b.scales.fromLots(fill),
+			Price:   o.Price,
+			Side:    o.Side,
+		})
 	}
-	return
+	return lots
 }
 
-func (b *book) ClearBuySideByUnits(units decimal.Decimal) (clearedOrders []*model.Order) {
-	b.buyMu.Lock()
-	defer b.buyMu.Unlock()
-	clearedPrice := make([]decimal.Decimal, 0)
-	b.buyTree.Descend(func(item limitTreeNode) bool {
-		if item.LimitRef == nil {
-			return false
+// allocateLimit fills orders of a single level as shared out by the allocator
+// of the book. Orders of the incoming owner are dealt with by self-trade
+// prevention first, as all orders of the level take part in each round.
+// Iceberg orders that are used up show their next slice at the back of the
+// queue, which then joins the next round.
+func (b *book) allocateLimit(bl *bookLimit, lots int64, opts *ClearOptions, r *ClearResult) int64 {
+	for lots > 0 && bl.firstBookOrder != nil {
+		queue := make([]*bookOrder, 0, bl.CountOrders())
+		sizes := make([]int64, 0, cap(queue))
+		var next *bookOrder
+		for o := bl.firstBookOrder; o != nil && lots > 0; o = next {
+			next = o.nextBookOrder
+			if opts.preventsSelfTrade(o.OwnerID) {
+				lots = b.preventSelfTrade(bl, o, lots, opts.SelfTradePrevention, r)
+				continue
+			}
+			queue = append(queue, o)
+			sizes = append(sizes, o.lots)
+		}
+		if lots <= 0 || len(queue) == 0 {
+			break
 		}
-		o := item.LimitRef.firstBookOrder
-		for o != nil && units.IsPositive() {
-			order := o.Order.Clone()
-			if o.Order.Units.LessThanOrEqual(units) {
-				clearedOrders = append(clearedOrders, &order)
-				units = units.Sub(o.Order.Units)
-				item.LimitRef.RemoveOrder(o.Order)
+		fills := b.allocator.Allocate(sizes, lots, b.scales)
+		var filled int64
+		for i, o := range queue {
+			fill := min64(fills[i], o.lots)
+			if fill <= 0 {
+				continue
+			}
+			if fill == o.lots {
+				bl.removeOrder(o.ID)
+				if o.hiddenLots > 0 {
+					replenishIceberg(o)
+					bl.insertOrUpdate(o)
+				} else {
+					b.unindexOrder(o.ID)
+				}
 			} else {
-				order.Units = units.Copy()
-				o.Order.Units = o.Order.Units.Sub(units)
-				item.LimitRef.InsertOrUpdateOrder(o.Order)
-				clearedOrders = append(clearedOrders, &order)
-				units = decimal.Zero
+				bl.reduceOrder(o.ID, fill)
 			}
-			o = o.nextBookOrder
+			filled += fill
+			r.ClearedOrders = append(r.ClearedOrders, &model.Order{
+				ID:      o.ID,
+				OwnerID: o.OwnerID,
+				Units:   b.scales.fromLots(fill),
+				Price:   o.Price,
+				Side:    o.Side,
+			})
 		}
-		if units.IsPositive() {
-			clearedPrice = append(clearedPrice, item.Price)
-			return true
+		if filled <= 0 {
+			break
 		}
-		return false
-	})
-	// TODO: optimize these operations
-	for _, p := range clearedPrice {
-		b.buyTree.Delete(limitTreeNode{Price: p})
-		delete(b.buyLimitMap, p.String())
+		lots -= filled
+	}
+	return lots
+}
+
+// preventSelfTrade applies mode to a resting order that would trade with an
+// incoming order of the same owner, and returns the lots of the incoming
+// order that are still free to match.
+func (b *book) preventSelfTrade(bl *bookLimit, resting *bookOrder, lots int64, mode model.SelfTradePrevention, r *ClearResult) int64 {
+	cancelResting := func(cancelled int64) {
+		r.Cancellations = append(r.Cancellations, model.OrderCancellation{
+			OrderID: resting.ID,
+			Units:   b.scales.fromLots(cancelled),
+			Reason:  model.CancelReason_SelfTrade,
+		})
+	}
+	removeResting := func() {
+		bl.removeOrder(resting.ID)
+		b.unindexOrder(resting.ID)
+		cancelResting(resting.totalLots())
+	}
+	cancelTaker := func(cancelled int64) int64 {
+		r.takerCancelledLots += cancelled
+		return lots - cancelled
+	}
+
+	switch mode {
+	case model.SelfTradePrevention_CancelOldest:
+		removeResting()
+		return lots
+	case model.SelfTradePrevention_CancelBoth:
+		removeResting()
+		return cancelTaker(lots)
+	case model.SelfTradePrevention_DecrementAndCancel:
+		total := resting.totalLots()
+		if total <= lots {
+			removeResting()
+			return cancelTaker(total)
+		}
+		bl.decreaseOrder(resting.ID, lots)
+		cancelResting(lots)
+		return cancelTaker(lots)
+	default: // model.SelfTradePrevention_CancelNewest
+		return cancelTaker(lots)
+	}
+}
+
+// side returns the tree, the level map and the lock of one side of the book.
+func (b *book) side(side model.OrderSide) (*limitTree, map[int64]*bookLimit, *sync.RWMutex) {
+	if side == model.OrderSide_Buy {
+		return b.buyTree, b.buyLimitMap, &b.buyMu
 	}
-	if len(clearedPrice) > 0 {
+	return b.sellTree, b.sellLimitMap, &b.sellMu
+}
+
+// resetBest points the best limit of a side at the top of its tree. The side
+// lock must be held by the caller.
+func (b *book) resetBest(side model.OrderSide) {
+	if side == model.OrderSide_Buy {
 		n, _ := b.buyTree.Max()
 		b.highestBuy = n.LimitRef
+		return
 	}
-	return
+	n, _ := b.sellTree.Min()
+	b.lowestSell = n.LimitRef
 }
 
-func (b *book) ClearSellSideByUnits(units decimal.Decimal) (clearedOrders []*model.Order) {
-	b.sellMu.Lock()
-	defer b.sellMu.Unlock()
-	clearedPrice := make([]decimal.Decimal, 0)
-	b.sellTree.Ascend(func(item limitTreeNode) bool {
-		if item.LimitRef == nil {
-			return false
-		}
-		o := item.LimitRef.firstBookOrder
-		for o != nil && units.IsPositive() {
-			order := o.Order.Clone()
-			if o.Order.Units.LessThanOrEqual(units) {
-				clearedOrders = append(clearedOrders, &order)
-				units = units.Sub(o.Order.Units)
-				item.LimitRef.RemoveOrder(o.Order)
-			} else {
-				order.Units = units.Copy()
-				o.Order.Units = o.Order.Units.Sub(units)
-				item.LimitRef.InsertOrUpdateOrder(o.Order)
-				clearedOrders = append(clearedOrders, &order)
-				units = decimal.Zero
-			}
-			o = o.nextBookOrder
-		}
-		if units.IsPositive() {
-			clearedPrice = append(clearedPrice, item.Price)
-			return true
-		}
-		return false
-	})
-	// TODO: optimize these operations
-	for _, p := range clearedPrice {
-		b.sellTree.Delete(limitTreeNode{Price: p})
-		delete(b.sellLimitMap, p.String())
+// SetDepthListener registers fn to receive every depth update. fn is called
+// while the book is locked, so it must not call back into the book.
+func (b *book) SetDepthListener(fn func(model.DepthUpdate)) {
+	b.depthMu.Lock()
+	defer b.depthMu.Unlock()
+	b.depthListener = fn
+}
+
+// publishDepth numbers the current size of a level and passes it to the depth
+// listener. The side lock must be held by the caller, so that snapshots always
+// see levels and the sequence change together.
+func (b *book) publishDepth(side model.OrderSide, bl *bookLimit) {
+	b.depthMu.Lock()
+	defer b.depthMu.Unlock()
+	b.seq++
+	if b.depthListener != nil {
+		b.depthListener(model.DepthUpdate{
+			Seq:   b.seq,
+			Side:  side,
+			Price: bl.Price,
+			Size:  bl.Size,
+		})
 	}
-	if len(clearedPrice) > 0 {
-		n, _ := b.sellTree.Min()
-		b.lowestSell = n.LimitRef
+}
+
+func (b *book) sequence() uint64 {
+	b.depthMu.Lock()
+	defer b.depthMu.Unlock()
+	return b.seq
+}
+
+// removeStaleOrder takes an order with the same ID off the book unless it
+// rests at the same side and price as order.
+func (b *book) removeStaleOrder(order *bookOrder) {
+	b.indexMu.RLock()
+	old := b.orderIndex[order.ID]
+	b.indexMu.RUnlock()
+	if old == nil || (old.Side == order.Side && old.ticks == order.ticks) {
+		return
 	}
-	return
+	b.cancel(old.Side, old.ticks, old.ID)
+}
+
+// splitIceberg moves everything above the display size of an iceberg order
+// into its hidden reserve.
+func splitIceberg(order *bookOrder) {
+	if order.displayLots <= 0 || order.lots <= order.displayLots {
+		return
+	}
+	order.hiddenLots += order.lots - order.displayLots
+	order.lots = order.displayLots
+}
+
+// replenishIceberg shows the next slice of an iceberg order whose displayed
+// units are used up. The order has to be queued again at the back of its
+// level, losing time priority.
+func replenishIceberg(order *bookOrder) {
+	slice := min64(order.displayLots, order.hiddenLots)
+	order.lots = slice
+	order.hiddenLots -= slice
+}
+
+func (b *book) indexOrder(order *bookOrder) {
+	b.indexMu.Lock()
+	defer b.indexMu.Unlock()
+	b.orderIndex[order.ID] = order
+}
+
+func (b *book) unindexOrder(id string) {
+	b.indexMu.Lock()
+	defer b.indexMu.Unlock()
+	delete(b.orderIndex, id)
+}
+
+func min64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
 }
 
 func (b *book) GetFullSnapshot() *BookSnapshot {
 	sn := NewBookSnapshot()
+	b.buyMu.RLock()
+	defer b.buyMu.RUnlock()
+	b.sellMu.RLock()
+	defer b.sellMu.RUnlock()
+	sn.Sequence = b.sequence()
 	wg := sync.WaitGroup{}
 	wg.Add(1)
 	go func(wg *sync.WaitGroup, b *book) {
 		defer wg.Done()
-		b.buyMu.RLock()
-		defer b.buyMu.RUnlock()
 		b.buyTree.Descend(func(item limitTreeNode) bool {
 			if item.LimitRef == nil {
 				return false
@@ -360,8 +851,6 @@ func (b *book) GetFullSnapshot() *BookSnapshot {
 	wg.Add(1)
 	go func(wg *sync.WaitGroup, b *book) {
 		defer wg.Done()
-		b.sellMu.RLock()
-		defer b.sellMu.RUnlock()
 		b.sellTree.Ascend(func(item limitTreeNode) bool {
 			if item.LimitRef == nil {
 				return false
@@ -376,12 +865,15 @@ func (b *book) GetFullSnapshot() *BookSnapshot {
 
 func (b *book) GetSnapshotWithDepth(depth int) *BookSnapshot {
 	sn := NewBookSnapshot()
+	b.buyMu.RLock()
+	defer b.buyMu.RUnlock()
+	b.sellMu.RLock()
+	defer b.sellMu.RUnlock()
+	sn.Sequence = b.sequence()
 	wg := sync.WaitGroup{}
 	wg.Add(1)
 	go func(wg *sync.WaitGroup, b *book, depth int) {
 		defer wg.Done()
-		b.buyMu.RLock()
-		defer b.buyMu.RUnlock()
 		count := 0
 		b.buyTree.Descend(func(item limitTreeNode) bool {
 			if item.LimitRef == nil || count == depth {
@@ -395,8 +887,6 @@ func (b *book) GetSnapshotWithDepth(depth int) *BookSnapshot {
 	wg.Add(1)
 	go func(wg *sync.WaitGroup, b *book, depth int) {
 		defer wg.Done()
-		b.sellMu.RLock()
-		defer b.sellMu.RUnlock()
 		count := 0
 		b.sellTree.Ascend(func(item limitTreeNode) bool {
 			if item.LimitRef == nil || count == depth {
@@ -410,3 +900,53 @@ func (b *book) GetSnapshotWithDepth(depth int) *BookSnapshot {
 	wg.Wait()
 	return sn
 }
+
+// GetTotalSnapshot is like GetFullSnapshot, but counts the hidden units of
+// iceberg orders in the size of each level.
+func (b *book) GetTotalSnapshot() *BookSnapshot {
+	sn := NewBookSnapshot()
+	b.buyMu.RLock()
+	defer b.buyMu.RUnlock()
+	b.sellMu.RLock()
+	defer b.sellMu.RUnlock()
+	sn.Sequence = b.sequence()
+	b.buyTree.Descend(func(item limitTreeNode) bool {
+		if item.LimitRef == nil {
+			return false
+		}
+		sn.Buys = append(sn.Buys, NewBookSnapshotRecord(item.LimitRef.Price, item.LimitRef.TotalSize()))
+		return true
+	})
+	b.sellTree.Ascend(func(item limitTreeNode) bool {
+		if item.LimitRef == nil {
+			return false
+		}
+		sn.Sells = append(sn.Sells, NewBookSnapshotRecord(item.LimitRef.Price, item.LimitRef.TotalSize()))
+		return true
+	})
+	return sn
+}
+
+func (b *book) GetL3Snapshot() *L3Snapshot {
+	sn := NewL3Snapshot()
+	b.buyMu.RLock()
+	defer b.buyMu.RUnlock()
+	b.sellMu.RLock()
+	defer b.sellMu.RUnlock()
+	sn.Sequence = b.sequence()
+	b.buyTree.Descend(func(item limitTreeNode) bool {
+		if item.LimitRef == nil {
+			return false
+		}
+		sn.Buys = item.LimitRef.appendL3Records(sn.Buys)
+		return true
+	})
+	b.sellTree.Ascend(func(item limitTreeNode) bool {
+		if item.LimitRef == nil {
+			return false
+		}
+		sn.Sells = item.LimitRef.appendL3Records(sn.Sells)
+		return true
+	})
+	return sn
+}
@@ -7,10 +7,17 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+// bookLimit is a price level. Size and Volume are the displayed units and
+// their value, kept in step with the lots of the level for callers outside
+// the book.
 type bookLimit struct {
 	Price          decimal.Decimal
 	Size           decimal.Decimal
 	Volume         decimal.Decimal
+	ticks          int64
+	lots           int64
+	hiddenLots     int64
+	scales         Scales
 	firstBookOrder *bookOrder
 	lastBookOrder  *bookOrder
 	bookOrderMap   map[string]*bookOrder
@@ -18,50 +25,109 @@ type bookLimit struct {
 }
 
 func NewBookLimit() *bookLimit {
+	return newBookLimit(decimal.NewFromFloat(0), DefaultScales)
+}
+
+func newBookLimit(price decimal.Decimal, scales Scales) *bookLimit {
 	return &bookLimit{
-		Price:        decimal.NewFromFloat(0),
-		Size:         decimal.NewFromFloat(0),
-		Volume:       decimal.NewFromFloat(0),
+		Price:        price,
+		Size:         decimal.Zero,
+		Volume:       decimal.Zero,
+		ticks:        scales.toTicks(price),
+		scales:       scales,
 		bookOrderMap: make(map[string]*bookOrder),
 	}
 }
 
+// TotalSize returns the units resting at the level, hidden units included.
+func (bl *bookLimit) TotalSize() decimal.Decimal {
+	return bl.scales.fromLots(bl.totalLots())
+}
+
+func (bl *bookLimit) totalLots() int64 {
+	bl.mu.RLock()
+	defer bl.mu.RUnlock()
+	return bl.lots + bl.hiddenLots
+}
+
+// InsertOrUpdateOrder queues order at the back of the level. If an order with
+// the same ID is already queued, it is replaced in place and keeps its queue
+// position, which callers must only rely on when the order's priority is not
+// meant to change.
 func (bl *bookLimit) InsertOrUpdateOrder(order *model.Order) (isUpdate bool) {
-	bl.mu.Lock()
-	defer bl.mu.Unlock()
-	o := bl.bookOrderMap[order.ID]
-	if o != nil {
-		bl.updateSize(o.Order.Units.Neg())
-		o.Order = order
-		bl.updateSize(o.Order.Units)
-		isUpdate = true
+	_, isUpdate = bl.insertOrUpdate(newBookOrder(order, bl.scales))
+	return
+}
+
+// RemoveOrder takes order off the level and copies its remaining units into
+// order.
+func (bl *bookLimit) RemoveOrder(order *model.Order) (removed bool) {
+	o := bl.removeOrder(order.ID)
+	if o == nil {
 		return
 	}
-	nbo := &bookOrder{
-		Order: order,
+	order.Units = bl.scales.fromLots(o.lots)
+	order.HiddenUnits = bl.scales.fromLots(o.hiddenLots)
+	return true
+}
+
+// ReduceOrder takes units off a resting order in place, so the order keeps
+// its position in the queue.
+func (bl *bookLimit) ReduceOrder(id string, units decimal.Decimal) {
+	bl.reduceOrder(id, bl.scales.toLots(units))
+}
+
+func (bl *bookLimit) IsEmpty() bool {
+	bl.mu.RLock()
+	defer bl.mu.RUnlock()
+	return bl.firstBookOrder == nil
+}
+
+func (bl *bookLimit) CountOrders() int {
+	bl.mu.RLock()
+	defer bl.mu.RUnlock()
+	return len(bl.bookOrderMap)
+}
+
+// insertOrUpdate queues o, or copies it onto the queued order with the same
+// ID, and returns the order that is now queued.
+func (bl *bookLimit) insertOrUpdate(o *bookOrder) (queued *bookOrder, isUpdate bool) {
+	bl.mu.Lock()
+	defer bl.mu.Unlock()
+	if queued = bl.bookOrderMap[o.ID]; queued != nil {
+		bl.updateSize(o.lots - queued.lots)
+		bl.hiddenLots += o.hiddenLots - queued.hiddenLots
+		queued.OwnerID = o.OwnerID
+		queued.lots = o.lots
+		queued.hiddenLots = o.hiddenLots
+		queued.displayLots = o.displayLots
+		return queued, true
 	}
 	if bl.firstBookOrder == nil {
-		bl.firstBookOrder = nbo
-		bl.lastBookOrder = nbo
-		bl.Price = order.Price
+		bl.firstBookOrder = o
+		bl.lastBookOrder = o
+		bl.Price = o.Price
+		bl.ticks = o.ticks
+		o.prevBookOrder = nil
 	} else {
-		bl.lastBookOrder.nextBookOrder = nbo
-		nbo.prevBookOrder = bl.lastBookOrder
-		bl.lastBookOrder = nbo
+		bl.lastBookOrder.nextBookOrder = o
+		o.prevBookOrder = bl.lastBookOrder
+		bl.lastBookOrder = o
 	}
-	bl.updateSize(order.Units)
-	bl.bookOrderMap[order.ID] = nbo
-	return
+	o.nextBookOrder = nil
+	bl.updateSize(o.lots)
+	bl.hiddenLots += o.hiddenLots
+	bl.bookOrderMap[o.ID] = o
+	return o, false
 }
 
-func (bl *bookLimit) RemoveOrder(order *model.Order) {
+func (bl *bookLimit) removeOrder(id string) *bookOrder {
 	bl.mu.Lock()
 	defer bl.mu.Unlock()
-	o := bl.bookOrderMap[order.ID]
+	o := bl.bookOrderMap[id]
 	if o == nil {
-		return
+		return nil
 	}
-	order.Units = o.Order.Units.Copy()
 	if o.prevBookOrder != nil {
 		o.prevBookOrder.nextBookOrder = o.nextBookOrder
 	}
@@ -74,26 +140,58 @@ func (bl *bookLimit) RemoveOrder(order *model.Order) {
 	if o == bl.lastBookOrder {
 		bl.lastBookOrder = o.prevBookOrder
 	}
-	bl.updateSize(o.Order.Units.Neg())
-	delete(bl.bookOrderMap, order.ID)
+	o.prevBookOrder = nil
+	o.nextBookOrder = nil
+	bl.updateSize(-o.lots)
+	bl.hiddenLots -= o.hiddenLots
+	delete(bl.bookOrderMap, id)
+	return o
 }
 
-func (bl *bookLimit) IsEmpty() bool {
-	bl.mu.RLock()
-	defer bl.mu.RUnlock()
-	return bl.firstBookOrder == nil
+func (bl *bookLimit) reduceOrder(id string, lots int64) {
+	bl.mu.Lock()
+	defer bl.mu.Unlock()
+	o := bl.bookOrderMap[id]
+	if o == nil {
+		return
+	}
+	o.lots -= lots
+	bl.updateSize(-lots)
 }
 
-func (bl *bookLimit) CountOrders() int {
+// decreaseOrder takes lots off a resting order in place, from the hidden
+// reserve of an iceberg order first.
+func (bl *bookLimit) decreaseOrder(id string, lots int64) {
+	bl.mu.Lock()
+	defer bl.mu.Unlock()
+	o := bl.bookOrderMap[id]
+	if o == nil {
+		return
+	}
+	fromHidden := min64(o.hiddenLots, lots)
+	o.hiddenLots -= fromHidden
+	bl.hiddenLots -= fromHidden
+	o.lots -= lots - fromHidden
+	bl.updateSize(fromHidden - lots)
+}
+
+func (bl *bookLimit) appendL3Records(records []*l3SnapshotRecord) []*l3SnapshotRecord {
 	bl.mu.RLock()
 	defer bl.mu.RUnlock()
-	return len(bl.bookOrderMap)
+	position := 0
+	for o := bl.firstBookOrder; o != nil; o = o.nextBookOrder {
+		order := o.toOrder(bl.scales)
+		records = append(records, NewL3SnapshotRecord(&order, position))
+		position++
+	}
+	return records
 }
 
-func (bl *bookLimit) updateSize(change decimal.Decimal) {
-	bl.Size = bl.Size.Add(change)
-	if bl.Size.IsNegative() {
-		bl.Size = decimal.NewFromFloat(0)
+func (bl *bookLimit) updateSize(change int64) {
+	bl.lots += change
+	if bl.lots < 0 {
+		bl.lots = 0
 	}
+	bl.Size = bl.scales.fromLots(bl.lots)
 	bl.Volume = bl.Size.Mul(bl.Price)
 }
@@ -1,9 +1,51 @@
 package orderbook
 
-import "github.com/dylantkx/matching-engine-core/model"
+import (
+	"github.com/dylantkx/matching-engine-core/model"
+	"github.com/shopspring/decimal"
+)
 
+// bookOrder is a resting order. Price is kept as given for reporting, while
+// matching only looks at ticks and lots. For an iceberg order lots is the
+// displayed slice, hiddenLots the reserve and displayLots the slice size.
 type bookOrder struct {
-	Order         *model.Order
+	ID            string
+	OwnerID       string
+	Side          model.OrderSide
+	Price         decimal.Decimal
+	ticks         int64
+	lots          int64
+	hiddenLots    int64
+	displayLots   int64
 	prevBookOrder *bookOrder
 	nextBookOrder *bookOrder
 }
+
+func newBookOrder(order *model.Order, scales Scales) *bookOrder {
+	return &bookOrder{
+		ID:          order.ID,
+		OwnerID:     order.OwnerID,
+		Side:        order.Side,
+		Price:       order.Price,
+		ticks:       scales.toTicks(order.Price),
+		lots:        scales.toLots(order.Units),
+		hiddenLots:  scales.toLots(order.HiddenUnits),
+		displayLots: scales.toLots(order.DisplayUnits),
+	}
+}
+
+func (o *bookOrder) totalLots() int64 {
+	return o.lots + o.hiddenLots
+}
+
+func (o *bookOrder) toOrder(scales Scales) model.Order {
+	return model.Order{
+		ID:           o.ID,
+		OwnerID:      o.OwnerID,
+		Units:        scales.fromLots(o.lots),
+		Price:        o.Price,
+		Side:         o.Side,
+		DisplayUnits: scales.fromLots(o.displayLots),
+		HiddenUnits:  scales.fromLots(o.hiddenLots),
+	}
+}
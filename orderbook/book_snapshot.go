@@ -1,8 +1,11 @@
 package orderbook
 
+// BookSnapshot is the depth of a book as of the depth update numbered
+// Sequence. Updates with a higher sequence apply on top of it.
 type BookSnapshot struct {
-	Buys  []*bookSnapshotRecord `json:"buys"`
-	Sells []*bookSnapshotRecord `json:"sells"`
+	Buys     []*bookSnapshotRecord `json:"buys"`
+	Sells    []*bookSnapshotRecord `json:"sells"`
+	Sequence uint64                `json:"sequence,omitempty"`
 }
 
 func NewBookSnapshot() *BookSnapshot {
@@ -1,6 +1,7 @@
 package orderbook_test
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 
@@ -169,3 +170,387 @@ func TestGetTotalSellUnitsToPrice(t *testing.T) {
 		t.Fatalf("expect to units = 1, but got %s", units)
 	}
 }
+
+func TestCancelOrderByID(t *testing.T) {
+	b := orderbook.NewBook()
+
+	b.AddBuyOrder(model.Order{
+		ID:    "1",
+		Units: decimal.NewFromFloat(1),
+		Price: decimal.NewFromFloat(100),
+		Side:  model.OrderSide_Buy,
+	})
+	b.AddBuyOrder(model.Order{
+		ID:    "2",
+		Units: decimal.NewFromFloat(2),
+		Price: decimal.NewFromFloat(90),
+		Side:  model.OrderSide_Buy,
+	})
+
+	cancels, err := b.CancelOrderByID("1")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if len(cancels) != 1 || !cancels[0].Units.Equal(decimal.NewFromFloat(1)) {
+		t.Fatalf("wrong cancel output: %+v", cancels)
+	}
+	if !b.GetHighestBuy().Price.Equal(decimal.NewFromFloat(90)) {
+		t.Fatalf("expect highest buy to be 90, but got %s", b.GetHighestBuy().Price)
+	}
+
+	var notFound *orderbook.OrderNotFoundError
+	if _, err := b.CancelOrderByID("1"); !errors.As(err, &notFound) || notFound.OrderID != "1" {
+		t.Fatalf("expect order not found error, but got %v", err)
+	}
+}
+
+func TestOrderIndexFollowsFills(t *testing.T) {
+	b := orderbook.NewBook()
+
+	b.AddSellOrder(model.Order{
+		ID:    "1",
+		Units: decimal.NewFromFloat(1),
+		Price: decimal.NewFromFloat(100),
+		Side:  model.OrderSide_Sell,
+	})
+	b.AddSellOrder(model.Order{
+		ID:    "2",
+		Units: decimal.NewFromFloat(2),
+		Price: decimal.NewFromFloat(100),
+		Side:  model.OrderSide_Sell,
+	})
+
+	b.ClearSellSideByUnits(decimal.NewFromFloat(1.5))
+
+	if _, ok := b.GetOrder("1"); ok {
+		t.Fatalf("expect filled order to be removed from the index")
+	}
+	o, ok := b.GetOrder("2")
+	if !ok || !o.Units.Equal(decimal.NewFromFloat(1.5)) {
+		t.Fatalf("expect order 2 to have 1.5 units left, but got %+v", o)
+	}
+	units := b.GetTotalSellUnitsToPrice(decimal.NewFromFloat(100))
+	if !units.Equal(decimal.NewFromFloat(1.5)) {
+		t.Fatalf("expect to units = 1.5, but got %s", units)
+	}
+
+	b.ClearSellSideByUnits(decimal.NewFromFloat(1.5))
+	if b.GetLowestSell() != nil {
+		t.Fatalf("expect sell side to be empty")
+	}
+	if _, ok := b.GetOrder("2"); ok {
+		t.Fatalf("expect filled order to be removed from the index")
+	}
+}
+
+func TestIcebergOrder(t *testing.T) {
+	b := orderbook.NewBook()
+
+	b.AddSellOrder(model.Order{
+		ID:           "1",
+		Units:        decimal.NewFromFloat(5),
+		Price:        decimal.NewFromFloat(100),
+		Side:         model.OrderSide_Sell,
+		DisplayUnits: decimal.NewFromFloat(2),
+	})
+	b.AddSellOrder(model.Order{
+		ID:    "2",
+		Units: decimal.NewFromFloat(1),
+		Price: decimal.NewFromFloat(100),
+		Side:  model.OrderSide_Sell,
+	})
+
+	sn := b.GetFullSnapshot()
+	if len(sn.Sells) != 1 || !sn.Sells[0].Size.Equal(decimal.NewFromFloat(3)) {
+		t.Fatalf("expect only displayed units in snapshot, but got %+v", sn.Sells)
+	}
+
+	cleared := b.ClearSellSideByUnits(decimal.NewFromFloat(4))
+	want := []struct {
+		id    string
+		units float64
+	}{
+		{"1", 2},
+		{"2", 1},
+		{"1", 1},
+	}
+	if len(cleared) != len(want) {
+		t.Fatalf("expect %d fills but got %d", len(want), len(cleared))
+	}
+	for i, w := range want {
+		if cleared[i].ID != w.id || !cleared[i].Units.Equal(decimal.NewFromFloat(w.units)) {
+			t.Fatalf("wrong fill %d: %+v", i, cleared[i])
+		}
+	}
+
+	units := b.GetTotalSellUnitsToPrice(decimal.NewFromFloat(100))
+	if !units.Equal(decimal.NewFromFloat(1)) {
+		t.Fatalf("expect to units = 1, but got %s", units)
+	}
+	cancels, err := b.CancelOrderByID("1")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !cancels[0].Units.Equal(decimal.NewFromFloat(2)) {
+		t.Fatalf("expect to cancel displayed and hidden units, but got %s", cancels[0].Units)
+	}
+}
+
+func TestReAddOrderAtNewPrice(t *testing.T) {
+	b := orderbook.NewBook()
+
+	b.AddBuyOrder(model.Order{
+		ID:    "1",
+		Units: decimal.NewFromFloat(1),
+		Price: decimal.NewFromFloat(100),
+		Side:  model.OrderSide_Buy,
+	})
+	b.AddBuyOrder(model.Order{
+		ID:    "1",
+		Units: decimal.NewFromFloat(1),
+		Price: decimal.NewFromFloat(90),
+		Side:  model.OrderSide_Buy,
+	})
+
+	sn := b.GetFullSnapshot()
+	if len(sn.Buys) != 1 || !sn.Buys[0].Price.Equal(decimal.NewFromFloat(90)) {
+		t.Fatalf("expect only the level at 90, but got %+v", sn.Buys)
+	}
+}
+
+func TestBookWithScales(t *testing.T) {
+	b := orderbook.NewBook(orderbook.WithScales(orderbook.Scales{PriceScale: 1, UnitsScale: 2}))
+
+	b.AddSellOrder(model.Order{ID: "1", Units: decimal.NewFromFloat(1.25), Price: decimal.NewFromFloat(100.1)})
+	b.AddSellOrder(model.Order{ID: "2", Units: decimal.NewFromFloat(2), Price: decimal.NewFromFloat(100.2)})
+
+	if u := b.GetTotalSellUnitsToPrice(decimal.NewFromFloat(100.19)); !u.Equal(decimal.NewFromFloat(1.25)) {
+		t.Fatalf("expect 1.25 units up to 100.19 but got %s", u)
+	}
+	limit := decimal.NewFromFloat(100.15)
+	r := b.ClearSellSide(decimal.NewFromFloat(5), orderbook.ClearOptions{Price: &limit})
+	if len(r.ClearedOrders) != 1 || r.ClearedOrders[0].ID != "1" || !r.ClearedOrders[0].Units.Equal(decimal.NewFromFloat(1.25)) {
+		t.Fatalf("wrong cleared orders: %+v", r.ClearedOrders)
+	}
+	if o, ok := b.GetOrder("2"); !ok || !o.Price.Equal(decimal.NewFromFloat(100.2)) || !o.Units.Equal(decimal.NewFromFloat(2)) {
+		t.Fatalf("expect order 2 to rest untouched but got %+v", o)
+	}
+	if b.GetScales().CanRepresentUnits(decimal.NewFromFloat(0.001)) {
+		t.Fatalf("expect 0.001 units not to be representable")
+	}
+
+	err := b.AddSellOrder(model.Order{ID: "3", Units: decimal.NewFromFloat(1), Price: decimal.NewFromFloat(100.25)})
+	if !errors.Is(err, orderbook.ErrPrecisionExceeded) {
+		t.Fatalf("expect precision exceeded error, but got %v", err)
+	}
+	err = b.AddSellOrder(model.Order{ID: "4", Units: decimal.NewFromFloat(1.005), Price: decimal.NewFromFloat(100.3)})
+	if !errors.Is(err, orderbook.ErrPrecisionExceeded) {
+		t.Fatalf("expect precision exceeded error, but got %v", err)
+	}
+	if _, ok := b.GetOrder("3"); ok {
+		t.Fatalf("expect order 3 priced finer than the scale not to be added")
+	}
+	if _, ok := b.GetOrder("4"); ok {
+		t.Fatalf("expect order 4 sized finer than the scale not to be added")
+	}
+}
+
+func TestNewBookFromSnapshot(t *testing.T) {
+	b := orderbook.NewBook()
+
+	for i := 1; i <= 6; i++ {
+		b.AddSellOrder(model.Order{
+			ID:    fmt.Sprintf("%d", i),
+			Units: decimal.NewFromFloat(float64(i)),
+			Price: decimal.NewFromFloat(float64(100 + i%2)),
+			Side:  model.OrderSide_Sell,
+		})
+	}
+	b.AddSellOrder(model.Order{
+		ID:           "7",
+		Units:        decimal.NewFromFloat(5),
+		Price:        decimal.NewFromFloat(100),
+		Side:         model.OrderSide_Sell,
+		DisplayUnits: decimal.NewFromFloat(1),
+	})
+	b.AddBuyOrder(model.Order{
+		ID:    "8",
+		Units: decimal.NewFromFloat(2),
+		Price: decimal.NewFromFloat(90),
+		Side:  model.OrderSide_Buy,
+	})
+
+	sn := b.GetL3Snapshot()
+	if len(sn.Sells) != 7 || len(sn.Buys) != 1 {
+		t.Fatalf("expect 7 sells and 1 buy, but got %d and %d", len(sn.Sells), len(sn.Buys))
+	}
+	if sn.Sells[0].ID != "2" || sn.Sells[3].ID != "7" || sn.Sells[3].Position != 3 {
+		t.Fatalf("wrong queue order: %+v, %+v", sn.Sells[0], sn.Sells[3])
+	}
+
+	restored, err := orderbook.NewBookFromSnapshot(sn)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !restored.GetLowestSell().Price.Equal(decimal.NewFromFloat(100)) {
+		t.Fatalf("expect lowest sell to be 100, but got %s", restored.GetLowestSell().Price)
+	}
+	if !restored.GetHighestBuy().Price.Equal(decimal.NewFromFloat(90)) {
+		t.Fatalf("expect highest buy to be 90, but got %s", restored.GetHighestBuy().Price)
+	}
+
+	want := b.ClearSellSideByUnits(decimal.NewFromFloat(30))
+	got := restored.ClearSellSideByUnits(decimal.NewFromFloat(30))
+	if len(want) != len(got) {
+		t.Fatalf("expect %d fills but got %d", len(want), len(got))
+	}
+	for i := range want {
+		if want[i].ID != got[i].ID || !want[i].Units.Equal(got[i].Units) || !want[i].Price.Equal(got[i].Price) {
+			t.Fatalf("expect fill %+v, but got %+v", want[i], got[i])
+		}
+	}
+
+	sn.Sells = append(sn.Sells, sn.Sells[0])
+	if _, err := orderbook.NewBookFromSnapshot(sn); !errors.Is(err, orderbook.ErrInvalidSnapshot) {
+		t.Fatalf("expect invalid snapshot error, but got %v", err)
+	}
+}
+
+func TestClearSellSideByQuote(t *testing.T) {
+	b := orderbook.NewBook()
+	b.AddSellOrder(model.Order{ID: "1", Units: decimal.NewFromFloat(1), Price: decimal.NewFromFloat(100)})
+	b.AddSellOrder(model.Order{ID: "2", Units: decimal.NewFromFloat(3), Price: decimal.NewFromFloat(200)})
+
+	r := b.ClearSellSideByQuote(decimal.NewFromFloat(399), orderbook.ClearOptions{LotSize: decimal.NewFromFloat(0.5)})
+	if len(r.ClearedOrders) != 2 {
+		t.Fatalf("expect 2 fills but got %d", len(r.ClearedOrders))
+	}
+	if !r.ClearedOrders[1].Units.Equal(decimal.NewFromFloat(1)) {
+		t.Fatalf("expect 1 unit bought at 200 but got %s", r.ClearedOrders[1].Units)
+	}
+	if size := b.GetLowestSell().Size; !size.Equal(decimal.NewFromFloat(2)) {
+		t.Fatalf("expect 2 units left at 200 but got %s", size)
+	}
+}
+
+func benchmarkOrders(n int) []model.Order {
+	orders := make([]model.Order, 0, n)
+	for i := 0; i < n; i++ {
+		orders = append(orders, model.Order{
+			ID:    fmt.Sprintf("%d", i+1),
+			Units: decimal.New(int64(1+i%50), -1),
+			Price: decimal.New(int64(9000+i%1000), -2),
+			Side:  model.OrderSide_Sell,
+		})
+	}
+	return orders
+}
+
+func BenchmarkBookAddOrders(b *testing.B) {
+	orders := benchmarkOrders(b.N)
+	book := orderbook.NewBook()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for _, o := range orders {
+		book.AddSellOrder(o)
+	}
+}
+
+func BenchmarkBookAddAndClearOrders(b *testing.B) {
+	orders := benchmarkOrders(b.N)
+	book := orderbook.NewBook()
+	units := decimal.New(25, -1)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i, o := range orders {
+		book.AddSellOrder(o)
+		if i%10 == 9 {
+			book.ClearSellSideByUnits(units)
+		}
+	}
+}
+
+func BenchmarkBookTotalUnits(b *testing.B) {
+	book := orderbook.NewBook()
+	for _, o := range benchmarkOrders(10000) {
+		book.AddSellOrder(o)
+	}
+	price := decimal.New(9500, -2)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		book.GetTotalSellUnitsToPrice(price)
+	}
+}
+
+func TestHiddenUnitsFollowFillsAndDecreases(t *testing.T) {
+	b := orderbook.NewBook()
+	b.AddSellOrder(model.Order{
+		ID:           "1",
+		Units:        decimal.NewFromFloat(10),
+		Price:        decimal.NewFromFloat(100),
+		Side:         model.OrderSide_Sell,
+		DisplayUnits: decimal.NewFromFloat(2),
+	})
+	b.AddSellOrder(model.Order{
+		ID:           "2",
+		Units:        decimal.NewFromFloat(6),
+		Price:        decimal.NewFromFloat(100),
+		Side:         model.OrderSide_Sell,
+		DisplayUnits: decimal.NewFromFloat(3),
+	})
+	price := decimal.NewFromFloat(100)
+	if u := b.GetSellUnitsToPriceWithHidden(price); !u.Equal(decimal.NewFromFloat(16)) {
+		t.Fatalf("expect 16 units with hidden but got %s", u)
+	}
+
+	b.ClearSellSideByUnits(decimal.NewFromFloat(3))
+	if u := b.GetSellUnitsToPriceWithHidden(price); !u.Equal(decimal.NewFromFloat(13)) {
+		t.Fatalf("expect 13 units with hidden after a fill but got %s", u)
+	}
+	if err := b.DecreaseOrder("1", decimal.NewFromFloat(2)); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if u := b.GetSellUnitsToPriceWithHidden(price); !u.Equal(decimal.NewFromFloat(7)) {
+		t.Fatalf("expect 7 units with hidden after a decrease but got %s", u)
+	}
+	if _, err := b.CancelOrderByID("2"); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if u := b.GetSellUnitsToPriceWithHidden(price); !u.Equal(decimal.NewFromFloat(2)) {
+		t.Fatalf("expect 2 units with hidden after a cancel but got %s", u)
+	}
+}
+
+func TestGetSellSideFillableUnits(t *testing.T) {
+	b := orderbook.NewBook()
+	b.AddSellOrder(model.Order{
+		ID:           "1",
+		OwnerID:      "bob",
+		Units:        decimal.NewFromFloat(10),
+		Price:        decimal.NewFromFloat(100),
+		Side:         model.OrderSide_Sell,
+		DisplayUnits: decimal.NewFromFloat(2),
+	})
+	b.AddSellOrder(model.Order{ID: "2", OwnerID: "alice", Units: decimal.NewFromFloat(1), Price: decimal.NewFromFloat(100)})
+	b.AddSellOrder(model.Order{ID: "3", OwnerID: "bob", Units: decimal.NewFromFloat(3), Price: decimal.NewFromFloat(101)})
+
+	units := decimal.NewFromFloat(20)
+	price := decimal.NewFromFloat(101)
+	tests := []struct {
+		mode model.SelfTradePrevention
+		want float64
+	}{
+		{"", 14},
+		{model.SelfTradePrevention_CancelOldest, 13},
+		// the iceberg shows its next slice behind alice's order
+		{model.SelfTradePrevention_CancelNewest, 2},
+		{model.SelfTradePrevention_DecrementAndCancel, 2},
+	}
+	for _, tt := range tests {
+		got := b.GetSellSideFillableUnits(units, orderbook.ClearOptions{Price: &price, OwnerID: "alice", SelfTradePrevention: tt.mode})
+		if !got.Equal(decimal.NewFromFloat(tt.want)) {
+			t.Fatalf("%q: expect %v fillable units but got %s", tt.mode, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package orderbook
+
+import (
+	"github.com/dylantkx/matching-engine-core/model"
+	"github.com/shopspring/decimal"
+)
+
+// ClearOptions describe the incoming order that liquidity is cleared for.
+type ClearOptions struct {
+	// Price bounds the levels that can be cleared. Nil clears at any price.
+	Price *decimal.Decimal
+	// OwnerID and SelfTradePrevention decide how resting orders of the same
+	// owner are handled. No prevention is done when either is empty.
+	OwnerID             string
+	SelfTradePrevention model.SelfTradePrevention
+	// LotSize is the step units are bought in when clearing by quote. The
+	// smallest unit the book can hold is used when it is zero.
+	LotSize decimal.Decimal
+}
+
+type ClearResult struct {
+	ClearedOrders []*model.Order
+	// Cancellations holds resting orders cancelled by self-trade prevention.
+	Cancellations []model.OrderCancellation
+	// TakerCancelledUnits is how much of the incoming order self-trade
+	// prevention cancelled.
+	TakerCancelledUnits decimal.Decimal
+
+	takerCancelledLots int64
+}
+
+func (o *ClearOptions) preventsSelfTrade(ownerID string) bool {
+	return o.SelfTradePrevention != "" && o.OwnerID != "" && ownerID == o.OwnerID
+}
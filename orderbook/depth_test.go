@@ -0,0 +1,62 @@
+package orderbook_test
+
+import (
+	"testing"
+
+	"github.com/dylantkx/matching-engine-core/model"
+	"github.com/dylantkx/matching-engine-core/orderbook"
+	"github.com/shopspring/decimal"
+)
+
+func TestDepthUpdates(t *testing.T) {
+	b := orderbook.NewBook()
+	var updates []model.DepthUpdate
+	b.SetDepthListener(func(u model.DepthUpdate) {
+		updates = append(updates, u)
+	})
+
+	for i, p := range []float64{100, 100, 101} {
+		b.AddSellOrder(model.Order{
+			ID:    string(rune('a' + i)),
+			Units: decimal.NewFromFloat(1),
+			Price: decimal.NewFromFloat(p),
+			Side:  model.OrderSide_Sell,
+		})
+	}
+	b.AddBuyOrder(model.Order{
+		ID:    "d",
+		Units: decimal.NewFromFloat(2),
+		Price: decimal.NewFromFloat(90),
+		Side:  model.OrderSide_Buy,
+	})
+	sn := b.GetFullSnapshot()
+	if sn.Sequence != 4 {
+		t.Fatalf("expect snapshot sequence 4, but got %d", sn.Sequence)
+	}
+
+	b.ClearSellSideByUnits(decimal.NewFromFloat(2.5))
+	b.CancelOrderByID("d")
+
+	want := []struct {
+		side  model.OrderSide
+		price float64
+		size  float64
+	}{
+		{model.OrderSide_Sell, 100, 1},
+		{model.OrderSide_Sell, 100, 2},
+		{model.OrderSide_Sell, 101, 1},
+		{model.OrderSide_Buy, 90, 2},
+		{model.OrderSide_Sell, 100, 0},
+		{model.OrderSide_Sell, 101, 0.5},
+		{model.OrderSide_Buy, 90, 0},
+	}
+	if len(updates) != len(want) {
+		t.Fatalf("expect %d updates but got %d", len(want), len(updates))
+	}
+	for i, w := range want {
+		u := updates[i]
+		if u.Seq != uint64(i+1) || u.Side != w.side || !u.Price.Equal(decimal.NewFromFloat(w.price)) || !u.Size.Equal(decimal.NewFromFloat(w.size)) {
+			t.Fatalf("wrong update %d: %+v", i, u)
+		}
+	}
+}
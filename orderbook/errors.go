@@ -0,0 +1,41 @@
+package orderbook
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrOrderNotFound     = errors.New("order not found")
+	ErrInvalidDecrease   = errors.New("order can only be decreased to a positive size below its current size")
+	ErrInvalidSnapshot   = errors.New("invalid snapshot")
+	ErrPrecisionExceeded = errors.New("price or units exceed the precision of the book")
+)
+
+// OrderNotFoundError is returned when an order is not resting in the book.
+// It matches ErrOrderNotFound with errors.Is.
+type OrderNotFoundError struct {
+	OrderID string
+}
+
+func (e *OrderNotFoundError) Error() string {
+	return fmt.Sprintf("order %s not found", e.OrderID)
+}
+
+func (e *OrderNotFoundError) Is(target error) bool {
+	return target == ErrOrderNotFound
+}
+
+// PrecisionExceededError is returned when an order has a price or units finer
+// than the scales of the book. It matches ErrPrecisionExceeded with errors.Is.
+type PrecisionExceededError struct {
+	OrderID string
+}
+
+func (e *PrecisionExceededError) Error() string {
+	return fmt.Sprintf("order %s exceeds the precision of the book", e.OrderID)
+}
+
+func (e *PrecisionExceededError) Is(target error) bool {
+	return target == ErrPrecisionExceeded
+}
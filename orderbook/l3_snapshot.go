@@ -0,0 +1,17 @@
+package orderbook
+
+// L3Snapshot lists every resting order. Levels are ordered from the best
+// price outwards, and orders within a level by their queue position. Like
+// BookSnapshot it is taken as of the depth update numbered Sequence.
+type L3Snapshot struct {
+	Buys     []*l3SnapshotRecord `json:"buys"`
+	Sells    []*l3SnapshotRecord `json:"sells"`
+	Sequence uint64              `json:"sequence"`
+}
+
+func NewL3Snapshot() *L3Snapshot {
+	return &L3Snapshot{
+		Buys:  make([]*l3SnapshotRecord, 0),
+		Sells: make([]*l3SnapshotRecord, 0),
+	}
+}
@@ -0,0 +1,44 @@
+package orderbook
+
+import (
+	"github.com/dylantkx/matching-engine-core/model"
+	"github.com/shopspring/decimal"
+)
+
+// l3SnapshotRecord is one resting order. Units are the displayed units and
+// Position is the 0-based place of the order in the queue of its level.
+type l3SnapshotRecord struct {
+	ID           string          `json:"id"`
+	OwnerID      string          `json:"ownerId,omitempty"`
+	Side         model.OrderSide `json:"side"`
+	Price        decimal.Decimal `json:"price"`
+	Units        decimal.Decimal `json:"units"`
+	DisplayUnits decimal.Decimal `json:"displayUnits"`
+	HiddenUnits  decimal.Decimal `json:"hiddenUnits"`
+	Position     int             `json:"position"`
+}
+
+func NewL3SnapshotRecord(order *model.Order, position int) *l3SnapshotRecord {
+	return &l3SnapshotRecord{
+		ID:           order.ID,
+		OwnerID:      order.OwnerID,
+		Side:         order.Side,
+		Price:        order.Price,
+		Units:        order.Units,
+		DisplayUnits: order.DisplayUnits,
+		HiddenUnits:  order.HiddenUnits,
+		Position:     position,
+	}
+}
+
+func (r *l3SnapshotRecord) order() model.Order {
+	return model.Order{
+		ID:           r.ID,
+		OwnerID:      r.OwnerID,
+		Units:        r.Units,
+		Price:        r.Price,
+		Side:         r.Side,
+		DisplayUnits: r.DisplayUnits,
+		HiddenUnits:  r.HiddenUnits,
+	}
+}
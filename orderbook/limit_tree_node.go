@@ -1,8 +1,6 @@
 package orderbook
 
-import "github.com/shopspring/decimal"
-
 type limitTreeNode struct {
-	Price    decimal.Decimal
+	Ticks    int64
 	LimitRef *bookLimit
 }
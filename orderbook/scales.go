@@ -0,0 +1,113 @@
+package orderbook
+
+import (
+	"math"
+
+	"github.com/dylantkx/matching-engine-core/model"
+	"github.com/shopspring/decimal"
+)
+
+var DefaultScales = Scales{PriceScale: 8, UnitsScale: 8}
+
+var pow10 = [...]int64{
+	1, 1e1, 1e2, 1e3, 1e4, 1e5, 1e6, 1e7, 1e8, 1e9,
+	1e10, 1e11, 1e12, 1e13, 1e14, 1e15, 1e16, 1e17, 1e18,
+}
+
+// Scales are the number of decimal places the book keeps prices and units
+// with. Inside the book a price is an int64 count of ticks of 10^-PriceScale
+// and units are an int64 count of lots of 10^-UnitsScale.
+type Scales struct {
+	PriceScale int32 `json:"priceScale"`
+	UnitsScale int32 `json:"unitsScale"`
+}
+
+// CanRepresentPrice tells if price is a whole number of ticks that fits in
+// an int64.
+func (s Scales) CanRepresentPrice(price decimal.Decimal) bool {
+	_, exact, ok := shift(price, s.PriceScale)
+	return exact && ok
+}
+
+// CanRepresentUnits tells if units are a whole number of lots that fits in
+// an int64.
+func (s Scales) CanRepresentUnits(units decimal.Decimal) bool {
+	_, exact, ok := shift(units, s.UnitsScale)
+	return exact && ok
+}
+
+func (s Scales) canRepresentOrder(order *model.Order) bool {
+	return s.CanRepresentPrice(order.Price) && s.CanRepresentUnits(order.Units) &&
+		s.CanRepresentUnits(order.HiddenUnits) && s.CanRepresentUnits(order.DisplayUnits)
+}
+
+func (s Scales) toTicks(price decimal.Decimal) int64 {
+	n, _, _ := shift(price, s.PriceScale)
+	return n
+}
+
+// floorTicks and ceilTicks round a price that is not a whole number of ticks
+// to the nearest tick below and above it.
+func (s Scales) floorTicks(price decimal.Decimal) int64 {
+	n, exact, ok := shift(price, s.PriceScale)
+	if ok && !exact && price.IsNegative() {
+		n--
+	}
+	return n
+}
+
+func (s Scales) ceilTicks(price decimal.Decimal) int64 {
+	n, exact, ok := shift(price, s.PriceScale)
+	if ok && !exact && price.IsPositive() {
+		n++
+	}
+	return n
+}
+
+func (s Scales) toLots(units decimal.Decimal) int64 {
+	n, _, _ := shift(units, s.UnitsScale)
+	return n
+}
+
+func (s Scales) fromLots(lots int64) decimal.Decimal {
+	return decimal.New(lots, -s.UnitsScale)
+}
+
+// shift returns d * 10^places truncated towards zero, and whether nothing was
+// truncated. Values beyond the range of an int64 are clamped and reported as
+// not ok. It works on the coefficient of d directly, as going through decimal
+// arithmetic allocates on every call.
+func shift(d decimal.Decimal, places int32) (n int64, exact, ok bool) {
+	if !d.Coefficient().IsInt64() {
+		shifted := d.Shift(places)
+		whole := shifted.Truncate(0)
+		n = clamp(whole)
+		return n, shifted.Equal(whole), whole.Equal(decimal.NewFromInt(n))
+	}
+	c := d.CoefficientInt64()
+	exp := d.Exponent() + places
+	if exp < 0 {
+		if int(-exp) >= len(pow10) {
+			return 0, c == 0, true
+		}
+		p := pow10[-exp]
+		return c / p, c%p == 0, true
+	}
+	for ; exp > 0; exp-- {
+		if c > math.MaxInt64/10 || c < math.MinInt64/10 {
+			return clamp(d.Shift(places)), true, false
+		}
+		c *= 10
+	}
+	return c, true, true
+}
+
+func clamp(d decimal.Decimal) int64 {
+	switch {
+	case d.GreaterThan(decimal.NewFromInt(math.MaxInt64)):
+		return math.MaxInt64
+	case d.LessThan(decimal.NewFromInt(math.MinInt64)):
+		return math.MinInt64
+	}
+	return d.IntPart()
+}
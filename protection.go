@@ -0,0 +1,161 @@
+package matchingenginecore
+
+import (
+	"time"
+
+	"github.com/dylantkx/matching-engine-core/model"
+	"github.com/shopspring/decimal"
+)
+
+// checkPriceBand returns the worst price an order on side may trade at under
+// the price band, or nil if no band applies. It also tells if the order, for
+// units up to limit (nil for a market order), would trade beyond that price.
+func (me *MatchingEngine) checkPriceBand(side model.OrderSide, units decimal.Decimal, limit *decimal.Decimal) (band *decimal.Decimal, breach bool) {
+	band = me.bandPrice(side)
+	if band == nil {
+		return nil, false
+	}
+	price := *band
+	var inBand, total decimal.Decimal
+	if side == model.OrderSide_Buy {
+		worst := me.book.GetHighestSell()
+		if worst == nil {
+			return
+		}
+		to := worst.Price
+		if limit != nil {
+			to = decimal.Min(to, *limit)
+		}
+		if !to.GreaterThan(price) {
+			return
+		}
+		inBand = me.book.GetSellUnitsToPriceWithHidden(price)
+		total = me.book.GetSellUnitsToPriceWithHidden(to)
+	} else {
+		worst := me.book.GetLowestBuy()
+		if worst == nil {
+			return
+		}
+		to := worst.Price
+		if limit != nil {
+			to = decimal.Max(to, *limit)
+		}
+		if !to.LessThan(price) {
+			return
+		}
+		inBand = me.book.GetBuyUnitsFromPriceWithHidden(price)
+		total = me.book.GetBuyUnitsFromPriceWithHidden(to)
+	}
+	return band, inBand.LessThan(units) && total.GreaterThan(inBand)
+}
+
+// bandPrice returns the worst price an order on side may trade at under the
+// price band, or nil if no band applies.
+func (me *MatchingEngine) bandPrice(side model.OrderSide) *decimal.Decimal {
+	if !me.band.Percent.IsPositive() || !me.lastPrice.IsPositive() {
+		return nil
+	}
+	offset := me.lastPrice.Mul(me.band.Percent).Div(decimal.NewFromInt(100))
+	price := me.lastPrice.Sub(offset)
+	if side == model.OrderSide_Buy {
+		price = me.lastPrice.Add(offset)
+	}
+	return &price
+}
+
+// circuitBreaker watches the trade prices within its window and halts the
+// engine when they spread too far.
+type circuitBreaker struct {
+	config    model.CircuitBreaker
+	prices    *rollingTicker
+	haltUntil time.Time
+}
+
+func newCircuitBreaker(config model.CircuitBreaker) *circuitBreaker {
+	return &circuitBreaker{
+		config: config,
+		prices: newRollingTicker(config.Window),
+	}
+}
+
+// addTrades tells if trades move the price more than allowed.
+func (cb *circuitBreaker) addTrades(trades []model.Trade) bool {
+	for _, tr := range trades {
+		cb.prices.addTrade(tr)
+	}
+	tk := cb.prices.ticker(trades[len(trades)-1].EventTime.Time)
+	limit := tk.Low.Mul(decimal.NewFromInt(100).Add(cb.config.Percent)).Div(decimal.NewFromInt(100))
+	return tk.High.GreaterThan(limit)
+}
+
+// tripCircuitBreaker feeds trades to the circuit breaker and halts the engine
+// for the cooling-off period if it trips.
+func (me *MatchingEngine) tripCircuitBreaker(r *model.MatchResult, trades []model.Trade) bool {
+	if me.breaker == nil || me.session != model.SessionState_Open || !me.breaker.addTrades(trades) {
+		return false
+	}
+	hr, _ := me.setSession(model.SessionState_Halted, model.SessionChangeReason_CircuitBreaker)
+	r.Append(hr)
+	me.breaker.prices = newRollingTicker(me.breaker.config.Window)
+	me.breaker.haltUntil = me.now.Add(me.breaker.config.CoolingOff)
+	return true
+}
+
+// endCoolingOff reopens the engine once the cooling-off period of a tripped
+// circuit breaker is over.
+func (me *MatchingEngine) endCoolingOff() (r model.MatchResult) {
+	if me.breaker == nil || me.breaker.haltUntil.IsZero() || me.now.Before(me.breaker.haltUntil) {
+		return
+	}
+	r, _ = me.setSession(model.SessionState_Open, model.SessionChangeReason_CoolingOffEnded)
+	return
+}
+
+// protectionPrice returns the worst price a market order may trade at under
+// its own slippage protection, or nil if it has none.
+func (me *MatchingEngine) protectionPrice(order *model.OrderMarket) *decimal.Decimal {
+	var price *decimal.Decimal
+	if order.MaxSlippageBps.IsPositive() {
+		best := me.book.GetLowestSell()
+		if order.Side == model.OrderSide_Sell {
+			best = me.book.GetHighestBuy()
+		}
+		if best != nil {
+			offset := best.Price.Mul(order.MaxSlippageBps).Div(decimal.NewFromInt(10000))
+			p := best.Price.Sub(offset)
+			if order.Side == model.OrderSide_Buy {
+				p = best.Price.Add(offset)
+			}
+			price = &p
+		}
+	}
+	if order.ProtectionPrice.IsPositive() {
+		price = tighterPrice(order.Side, price, &order.ProtectionPrice)
+	}
+	return price
+}
+
+// tighterPrice returns the stricter of two price limits of an order on side,
+// either of which may be nil.
+func tighterPrice(side model.OrderSide, a, b *decimal.Decimal) *decimal.Decimal {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	case side == model.OrderSide_Buy && b.LessThan(*a), side == model.OrderSide_Sell && b.GreaterThan(*a):
+		return b
+	}
+	return a
+}
+
+// hasLiquidityBeyond tells if any order opposite to side rests at a price
+// worse than price.
+func (me *MatchingEngine) hasLiquidityBeyond(side model.OrderSide, price decimal.Decimal) bool {
+	if side == model.OrderSide_Buy {
+		worst := me.book.GetHighestSell()
+		return worst != nil && worst.Price.GreaterThan(price)
+	}
+	worst := me.book.GetLowestBuy()
+	return worst != nil && worst.Price.LessThan(price)
+}
@@ -0,0 +1,191 @@
+package matchingenginecore_test
+
+import (
+	"testing"
+	"time"
+
+	me "github.com/dylantkx/matching-engine-core"
+	"github.com/dylantkx/matching-engine-core/model"
+	"github.com/shopspring/decimal"
+)
+
+// newBandedEngine returns an engine that last traded at 100, with sells
+// resting at 105 and 120.
+func newBandedEngine(mode model.PriceBandMode) *me.MatchingEngine {
+	engine := me.NewMatchingEngine(me.WithPriceBand(model.PriceBand{
+		Percent: decimal.NewFromFloat(10),
+		Mode:    mode,
+	}))
+	addLimit(engine, "1", model.OrderSide_Sell, 1, 100)
+	addLimit(engine, "2", model.OrderSide_Buy, 1, 100)
+	addLimit(engine, "3", model.OrderSide_Sell, 1, 105)
+	addLimit(engine, "4", model.OrderSide_Sell, 5, 120)
+	return engine
+}
+
+func TestPriceBandReject(t *testing.T) {
+	engine := newBandedEngine(model.PriceBandMode_Reject)
+
+	r := engine.ProcessMarketOrder(&model.OrderMarket{
+		ID:    "5",
+		Units: decimal.NewFromFloat(3),
+		Side:  model.OrderSide_Buy,
+	})
+	if len(r.Trades) != 0 || len(r.Rejections) != 1 || r.Rejections[0].Reason != model.RejectReason_PriceBand {
+		t.Fatalf("expect market order to be rejected but got %+v", r)
+	}
+	r = addLimit(engine, "6", model.OrderSide_Buy, 3, 130)
+	if len(r.Trades) != 0 || len(r.Rejections) != 1 || r.Rejections[0].Reason != model.RejectReason_PriceBand {
+		t.Fatalf("expect limit order to be rejected but got %+v", r)
+	}
+
+	r = engine.ProcessMarketOrder(&model.OrderMarket{
+		ID:    "7",
+		Units: decimal.NewFromFloat(1),
+		Side:  model.OrderSide_Buy,
+	})
+	if len(r.Trades) != 1 || !r.Trades[0].Price.Equal(decimal.NewFromFloat(105)) {
+		t.Fatalf("expect order within the band to trade but got %+v", r)
+	}
+}
+
+func TestPriceBandTruncate(t *testing.T) {
+	engine := newBandedEngine(model.PriceBandMode_Truncate)
+
+	r := engine.ProcessMarketOrder(&model.OrderMarket{
+		ID:    "5",
+		Units: decimal.NewFromFloat(3),
+		Side:  model.OrderSide_Buy,
+	})
+	if len(r.Trades) != 1 || !r.Trades[0].Price.Equal(decimal.NewFromFloat(105)) {
+		t.Fatalf("expect a single trade within the band but got %+v", r.Trades)
+	}
+	if len(r.Cancellations) != 1 || r.Cancellations[0].Reason != model.CancelReason_PriceBand || !r.Cancellations[0].Units.Equal(decimal.NewFromFloat(2)) {
+		t.Fatalf("expect the rest to be cancelled but got %+v", r.Cancellations)
+	}
+	if u := engine.GetTotalSellUnitsToPrice(decimal.NewFromFloat(120)); !u.Equal(decimal.NewFromFloat(5)) {
+		t.Fatalf("expect the level outside the band untouched but got %s", u)
+	}
+}
+
+func TestPriceBandCountsHiddenUnits(t *testing.T) {
+	engine := newBandedEngine(model.PriceBandMode_Reject)
+	engine.ProcessLimitOrder(&model.OrderLimit{
+		ID:           "5",
+		Units:        decimal.NewFromFloat(10),
+		Price:        decimal.NewFromFloat(101),
+		Side:         model.OrderSide_Sell,
+		DisplayUnits: decimal.NewFromFloat(1),
+	})
+
+	r := engine.ProcessMarketOrder(&model.OrderMarket{
+		ID:    "6",
+		Units: decimal.NewFromFloat(5),
+		Side:  model.OrderSide_Buy,
+	})
+	if len(r.Rejections) != 0 || len(r.Cancellations) != 0 {
+		t.Fatalf("expect the order to fill within the band but got %+v", r)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	engine := me.NewMatchingEngine(me.WithCircuitBreaker(model.CircuitBreaker{
+		Percent:    decimal.NewFromFloat(5),
+		Window:     time.Minute,
+		CoolingOff: 5 * time.Minute,
+	}))
+	addLimit(engine, "1", model.OrderSide_Sell, 1, 100)
+	addLimit(engine, "2", model.OrderSide_Buy, 1, 100)
+	addLimit(engine, "3", model.OrderSide_Sell, 1, 104)
+	r := addLimit(engine, "4", model.OrderSide_Buy, 1, 104)
+	if r.SessionState != nil {
+		t.Fatalf("expect a move within the limit not to halt but got %+v", r.SessionState)
+	}
+
+	addLimit(engine, "5", model.OrderSide_Sell, 1, 106)
+	r = addLimit(engine, "6", model.OrderSide_Buy, 1, 106)
+	if len(r.Trades) != 1 || r.SessionState == nil || r.SessionState.To != model.SessionState_Halted ||
+		r.SessionState.Reason != model.SessionChangeReason_CircuitBreaker {
+		t.Fatalf("expect the circuit breaker to halt the engine but got %+v", r)
+	}
+	r = addLimit(engine, "7", model.OrderSide_Sell, 1, 106)
+	if len(r.Rejections) != 1 || r.Rejections[0].Reason != model.RejectReason_SessionHalted {
+		t.Fatalf("wrong rejection output: %+v", r.Rejections)
+	}
+
+	r = engine.ExpireOrders(time.Now().Add(10 * time.Minute))
+	if r.SessionState == nil || r.SessionState.To != model.SessionState_Open || r.SessionState.Reason != model.SessionChangeReason_CoolingOffEnded {
+		t.Fatalf("expect the engine to reopen after cooling off but got %+v", r.SessionState)
+	}
+	if s := engine.GetSessionState(); s != model.SessionState_Open {
+		t.Fatalf("expect session to be open but got %s", s)
+	}
+}
+
+// newThinEngine returns an engine with sells resting at 100, 100.5 and 102.
+func newThinEngine() *me.MatchingEngine {
+	engine := me.NewMatchingEngine()
+	addLimit(engine, "1", model.OrderSide_Sell, 1, 100)
+	addLimit(engine, "2", model.OrderSide_Sell, 1, 100.5)
+	addLimit(engine, "3", model.OrderSide_Sell, 2, 102)
+	return engine
+}
+
+func TestMarketOrderSlippageProtection(t *testing.T) {
+	tests := []struct {
+		name      string
+		order     model.OrderMarket
+		filled    float64
+		cancelled float64
+	}{
+		{"max slippage", model.OrderMarket{MaxSlippageBps: decimal.NewFromInt(100)}, 2, 1},
+		{"protection price", model.OrderMarket{ProtectionPrice: decimal.NewFromFloat(100.2)}, 1, 2},
+		{"tighter of both", model.OrderMarket{MaxSlippageBps: decimal.NewFromInt(300), ProtectionPrice: decimal.NewFromInt(100)}, 1, 2},
+	}
+	for _, tt := range tests {
+		engine := newThinEngine()
+		order := tt.order
+		order.ID = "4"
+		order.Units = decimal.NewFromFloat(3)
+		order.Side = model.OrderSide_Buy
+		r := engine.ProcessMarketOrder(&order)
+
+		filled := decimal.Zero
+		for _, tr := range r.Trades {
+			filled = filled.Add(tr.Units)
+		}
+		if !filled.Equal(decimal.NewFromFloat(tt.filled)) {
+			t.Fatalf("%s: expect %v units filled but got %s", tt.name, tt.filled, filled)
+		}
+		if len(r.Cancellations) != 1 || r.Cancellations[0].Reason != model.CancelReason_Slippage ||
+			!r.Cancellations[0].Units.Equal(decimal.NewFromFloat(tt.cancelled)) {
+			t.Fatalf("%s: expect %v units cancelled by slippage protection but got %+v", tt.name, tt.cancelled, r.Cancellations)
+		}
+	}
+}
+
+func TestMarketToLimit(t *testing.T) {
+	engine := newThinEngine()
+
+	r := engine.ProcessMarketOrder(&model.OrderMarket{
+		ID:             "4",
+		Units:          decimal.NewFromFloat(3),
+		Side:           model.OrderSide_Buy,
+		MaxSlippageBps: decimal.NewFromInt(100),
+		MarketToLimit:  true,
+	})
+	if len(r.Trades) != 2 || len(r.Cancellations) != 0 {
+		t.Fatalf("expect 2 trades and no cancellation but got %+v", r)
+	}
+	last := r.Reports[len(r.Reports)-1]
+	if last.ExecType != model.ExecType_Restated || !last.Price.Equal(decimal.NewFromFloat(100.5)) || !last.LeavesUnits.Equal(decimal.NewFromFloat(1)) {
+		t.Fatalf("expect the rest to be restated as a limit order but got %+v", last)
+	}
+	if p := engine.GetHighestBuyPrice(); !p.Equal(decimal.NewFromFloat(100.5)) {
+		t.Fatalf("expect the rest to rest at 100.5 but got %s", p)
+	}
+
+	if _, err := engine.CancelOrderByID("4"); err != nil {
+		t.Fatalf("expect the converted order to be cancelled but got %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+package matchingenginecore
+
+import (
+	"github.com/dylantkx/matching-engine-core/model"
+	"github.com/dylantkx/matching-engine-core/orderbook"
+	"github.com/shopspring/decimal"
+)
+
+// checkQuoteBand is checkPriceBand for a market buy spending quote, which
+// breaches the band if the sells within it can not take all of quote.
+func (me *MatchingEngine) checkQuoteBand(quote decimal.Decimal) (band *decimal.Decimal, breach bool) {
+	band = me.bandPrice(model.OrderSide_Buy)
+	if band == nil {
+		return
+	}
+	worst := me.book.GetHighestSell()
+	if worst == nil || !worst.Price.GreaterThan(*band) {
+		return
+	}
+	inBand := me.book.GetSellSideCost(me.book.GetSellUnitsToPriceWithHidden(*band), orderbook.ClearOptions{Price: band})
+	return band, inBand.LessThan(quote)
+}
+
+// processMarketQuoteOrder buys with the quote budget of order, never beyond
+// limit if one is given, in whole lots of the instrument. The unspent budget
+// is given back with the unfilled reason.
+func (me *MatchingEngine) processMarketQuoteOrder(order *model.OrderMarket, limit *decimal.Decimal, unfilled model.CancelReason) (r model.MatchResult) {
+	now := me.now
+	remainingQuote := order.QuoteAmount
+
+	opts := me.clearOptions(order.OwnerID, limit)
+	opts.LotSize = me.rules.LotSize
+	cr := me.book.ClearSellSideByQuote(order.QuoteAmount, opts)
+	for _, o := range cr.ClearedOrders {
+		me.addTrade(&r, model.Trade{
+			Symbol:       me.symbol,
+			BuyOrderID:   order.ID,
+			SellOrderID:  o.ID,
+			Units:        o.Units,
+			Price:        o.Price,
+			IsBuyerMaker: false,
+			EventTime:    model.Timestamp{Time: now},
+		})
+		remainingQuote = remainingQuote.Sub(o.Units.Mul(o.Price))
+	}
+	for _, c := range cr.Cancellations {
+		me.addCancellation(&r, c)
+	}
+	me.forgetInactiveOrders(cr)
+
+	if cr.TakerCancelledUnits.IsPositive() {
+		unfilled = model.CancelReason_SelfTrade
+	}
+	if remainingQuote.IsPositive() {
+		me.addCancellation(&r, model.OrderCancellation{
+			OrderID:     order.ID,
+			QuoteAmount: remainingQuote,
+			Reason:      unfilled,
+		})
+	}
+	return
+}
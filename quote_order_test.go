@@ -0,0 +1,99 @@
+package matchingenginecore_test
+
+import (
+	"testing"
+
+	me "github.com/dylantkx/matching-engine-core"
+	"github.com/dylantkx/matching-engine-core/model"
+	"github.com/shopspring/decimal"
+)
+
+func TestMarketBuyByQuote(t *testing.T) {
+	engine := me.NewMatchingEngine(me.WithInstrumentRules(model.InstrumentRules{
+		LotSize: decimal.NewFromFloat(0.1),
+	}))
+	addLimit(engine, "1", model.OrderSide_Sell, 1, 100)
+	addLimit(engine, "2", model.OrderSide_Sell, 2, 110)
+	addLimit(engine, "3", model.OrderSide_Sell, 5, 120)
+
+	r := engine.ProcessMarketOrder(&model.OrderMarket{
+		ID:          "4",
+		QuoteAmount: decimal.NewFromInt(400),
+		Side:        model.OrderSide_Buy,
+	})
+	expected := []float64{1, 2, 0.6}
+	if len(r.Trades) != len(expected) {
+		t.Fatalf("expect %d trades but got %d", len(expected), len(r.Trades))
+	}
+	for i, units := range expected {
+		if !r.Trades[i].Units.Equal(decimal.NewFromFloat(units)) {
+			t.Fatalf("expect trade %d of %v units but got %s", i, units, r.Trades[i].Units)
+		}
+	}
+	if len(r.Cancellations) != 1 || !r.Cancellations[0].QuoteAmount.Equal(decimal.NewFromInt(8)) || !r.Cancellations[0].Units.IsZero() {
+		t.Fatalf("expect 8 quote to be given back but got %+v", r.Cancellations)
+	}
+	if size := engine.GetOrderBookFullSnapshot().Sells[0].Size; !size.Equal(decimal.NewFromFloat(4.4)) {
+		t.Fatalf("expect 4.4 units left at 120 but got %s", size)
+	}
+}
+
+func TestMarketBuyByQuoteFilled(t *testing.T) {
+	engine := me.NewMatchingEngine()
+	addLimit(engine, "1", model.OrderSide_Sell, 2, 100)
+
+	r := engine.ProcessMarketOrder(&model.OrderMarket{
+		ID:          "2",
+		QuoteAmount: decimal.NewFromInt(150),
+		Side:        model.OrderSide_Buy,
+	})
+	if len(r.Trades) != 1 || !r.Trades[0].Units.Equal(decimal.NewFromFloat(1.5)) {
+		t.Fatalf("expect 1.5 units bought but got %+v", r.Trades)
+	}
+	if len(r.Cancellations) != 0 {
+		t.Fatalf("expect no cancellation but got %+v", r.Cancellations)
+	}
+	var status model.OrdStatus
+	for _, rep := range r.Reports {
+		if rep.OrderID == "2" {
+			status = rep.OrdStatus
+		}
+	}
+	if status != model.OrdStatus_Filled {
+		t.Fatalf("expect order to be filled but got %s", status)
+	}
+}
+
+func TestMarketSellByQuoteRejected(t *testing.T) {
+	engine := me.NewMatchingEngine()
+	addLimit(engine, "1", model.OrderSide_Buy, 2, 100)
+
+	r := engine.ProcessMarketOrder(&model.OrderMarket{
+		ID:          "2",
+		QuoteAmount: decimal.NewFromInt(100),
+		Side:        model.OrderSide_Sell,
+	})
+	if len(r.Rejections) != 1 || r.Rejections[0].Reason != model.RejectReason_InvalidQuoteAmount {
+		t.Fatalf("expect order to be rejected but got %+v", r)
+	}
+}
+
+func TestMarketBuyByQuoteWithinBand(t *testing.T) {
+	engine := newBandedEngine(model.PriceBandMode_Reject)
+	engine.ProcessLimitOrder(&model.OrderLimit{
+		ID:           "5",
+		Units:        decimal.NewFromFloat(10),
+		Price:        decimal.NewFromFloat(101),
+		Side:         model.OrderSide_Sell,
+		DisplayUnits: decimal.NewFromFloat(1),
+	})
+
+	r := engine.ProcessMarketOrder(&model.OrderMarket{
+		ID:          "6",
+		QuoteAmount: decimal.NewFromInt(606),
+		Side:        model.OrderSide_Buy,
+	})
+	if len(r.Rejections) != 0 || len(r.Cancellations) != 0 {
+		t.Fatalf("expect the budget to be spent within the band but got %+v", r)
+	}
+}
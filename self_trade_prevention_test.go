@@ -0,0 +1,193 @@
+package matchingenginecore_test
+
+import (
+	"testing"
+
+	me "github.com/dylantkx/matching-engine-core"
+	"github.com/dylantkx/matching-engine-core/model"
+	"github.com/shopspring/decimal"
+)
+
+func TestSelfTradePrevention(t *testing.T) {
+	type cancel struct {
+		id    string
+		units float64
+	}
+	tests := []struct {
+		mode        model.SelfTradePrevention
+		takerUnits  float64
+		trades      int
+		cancels     []cancel
+		restingSell float64
+	}{
+		{
+			mode:        model.SelfTradePrevention_CancelNewest,
+			takerUnits:  3,
+			trades:      0,
+			cancels:     []cancel{{"3", 3}},
+			restingSell: 3,
+		},
+		{
+			mode:        model.SelfTradePrevention_CancelOldest,
+			takerUnits:  3,
+			trades:      1,
+			cancels:     []cancel{{"1", 1}, {"3", 1}},
+			restingSell: 0,
+		},
+		{
+			mode:        model.SelfTradePrevention_CancelBoth,
+			takerUnits:  3,
+			trades:      0,
+			cancels:     []cancel{{"1", 1}, {"3", 3}},
+			restingSell: 2,
+		},
+		{
+			mode:        model.SelfTradePrevention_DecrementAndCancel,
+			takerUnits:  3,
+			trades:      1,
+			cancels:     []cancel{{"1", 1}, {"3", 1}},
+			restingSell: 0,
+		},
+		{
+			mode:        model.SelfTradePrevention_DecrementAndCancel,
+			takerUnits:  0.5,
+			trades:      0,
+			cancels:     []cancel{{"1", 0.5}, {"3", 0.5}},
+			restingSell: 2.5,
+		},
+	}
+
+	for _, tt := range tests {
+		engine := me.NewMatchingEngine(me.WithSelfTradePrevention(tt.mode))
+		sellOrders := []model.OrderLimit{
+			{
+				ID:      "1",
+				OwnerID: "alice",
+				Units:   decimal.NewFromFloat(1),
+				Price:   decimal.NewFromFloat(100),
+				Side:    model.OrderSide_Sell,
+			},
+			{
+				ID:      "2",
+				OwnerID: "bob",
+				Units:   decimal.NewFromFloat(2),
+				Price:   decimal.NewFromFloat(100),
+				Side:    model.OrderSide_Sell,
+			},
+		}
+		for _, ord := range sellOrders {
+			engine.ProcessLimitOrder(&ord)
+		}
+
+		r := engine.ProcessMarketOrder(&model.OrderMarket{
+			ID:      "3",
+			OwnerID: "alice",
+			Units:   decimal.NewFromFloat(tt.takerUnits),
+			Side:    model.OrderSide_Buy,
+		})
+		if len(r.Trades) != tt.trades {
+			t.Fatalf("%s: expect %d trades but got %d", tt.mode, tt.trades, len(r.Trades))
+		}
+		for _, tr := range r.Trades {
+			if tr.SellOrderID != "2" {
+				t.Fatalf("%s: wrong trade output: %+v", tt.mode, tr)
+			}
+		}
+		if len(r.Cancellations) != len(tt.cancels) {
+			t.Fatalf("%s: expect %d cancels but got %+v", tt.mode, len(tt.cancels), r.Cancellations)
+		}
+		for i, c := range tt.cancels {
+			got := r.Cancellations[i]
+			if got.OrderID != c.id || !got.Units.Equal(decimal.NewFromFloat(c.units)) {
+				t.Fatalf("%s: wrong cancel %d: %+v", tt.mode, i, got)
+			}
+			if got.Reason != model.CancelReason_SelfTrade && got.Reason != model.CancelReason_Unfilled {
+				t.Fatalf("%s: wrong cancel reason: %+v", tt.mode, got)
+			}
+		}
+		units := engine.GetTotalSellUnitsToPrice(decimal.NewFromFloat(100))
+		if !units.Equal(decimal.NewFromFloat(tt.restingSell)) {
+			t.Fatalf("%s: expect %v resting sell units, but got %s", tt.mode, tt.restingSell, units)
+		}
+	}
+}
+
+func TestFillOrKillWithSelfTradePrevention(t *testing.T) {
+	for _, mode := range []model.SelfTradePrevention{
+		model.SelfTradePrevention_CancelNewest,
+		model.SelfTradePrevention_CancelOldest,
+		model.SelfTradePrevention_CancelBoth,
+		model.SelfTradePrevention_DecrementAndCancel,
+	} {
+		engine := me.NewMatchingEngine(me.WithSelfTradePrevention(mode))
+		sellOrders := []model.OrderLimit{
+			{
+				ID:      "1",
+				OwnerID: "alice",
+				Units:   decimal.NewFromFloat(5),
+				Price:   decimal.NewFromFloat(100),
+				Side:    model.OrderSide_Sell,
+			},
+			{
+				ID:      "2",
+				OwnerID: "bob",
+				Units:   decimal.NewFromFloat(5),
+				Price:   decimal.NewFromFloat(100),
+				Side:    model.OrderSide_Sell,
+			},
+		}
+		for _, ord := range sellOrders {
+			engine.ProcessLimitOrder(&ord)
+		}
+
+		r := engine.ProcessLimitOrder(&model.OrderLimit{
+			ID:          "3",
+			OwnerID:     "alice",
+			Units:       decimal.NewFromFloat(10),
+			Price:       decimal.NewFromFloat(100),
+			Side:        model.OrderSide_Buy,
+			TimeInForce: model.TimeInForce_FOK,
+		})
+		if len(r.Trades) != 0 {
+			t.Fatalf("%s: expect no trades but got %+v", mode, r.Trades)
+		}
+		if len(r.Cancellations) != 1 {
+			t.Fatalf("%s: expect 1 cancel but got %+v", mode, r.Cancellations)
+		}
+		c := r.Cancellations[0]
+		if c.OrderID != "3" || !c.Units.Equal(decimal.NewFromFloat(10)) || c.Reason != model.CancelReason_FillOrKill {
+			t.Fatalf("%s: wrong cancel output: %+v", mode, c)
+		}
+		if units := engine.GetTotalSellUnitsToPrice(decimal.NewFromFloat(100)); !units.Equal(decimal.NewFromFloat(10)) {
+			t.Fatalf("%s: expect book to be untouched, but got %s units", mode, units)
+		}
+	}
+}
+
+func TestFillOrKillSkipsOwnOrdersWithCancelOldest(t *testing.T) {
+	engine := me.NewMatchingEngine(me.WithSelfTradePrevention(model.SelfTradePrevention_CancelOldest))
+	for i, owner := range []string{"alice", "bob", "carol"} {
+		engine.ProcessLimitOrder(&model.OrderLimit{
+			ID:      owner,
+			OwnerID: owner,
+			Units:   decimal.NewFromFloat(5),
+			Price:   decimal.NewFromInt(int64(100 + i)),
+			Side:    model.OrderSide_Sell,
+		})
+	}
+
+	r := engine.ProcessLimitOrder(&model.OrderLimit{
+		ID:          "1",
+		OwnerID:     "alice",
+		Units:       decimal.NewFromFloat(10),
+		Price:       decimal.NewFromFloat(102),
+		Side:        model.OrderSide_Buy,
+		TimeInForce: model.TimeInForce_FOK,
+	})
+	if len(r.Trades) != 2 {
+		t.Fatalf("expect 2 trades but got %+v", r.Trades)
+	}
+	if len(r.Cancellations) != 1 || r.Cancellations[0].OrderID != "alice" || r.Cancellations[0].Reason != model.CancelReason_SelfTrade {
+		t.Fatalf("wrong cancel output: %+v", r.Cancellations)
+	}
+}
@@ -0,0 +1,90 @@
+package matchingenginecore
+
+import (
+	"time"
+
+	"github.com/dylantkx/matching-engine-core/model"
+)
+
+// sessionTransitions lists the states each session state may move to.
+var sessionTransitions = map[model.SessionState][]model.SessionState{
+	model.SessionState_PreOpen: {model.SessionState_Open, model.SessionState_Halted, model.SessionState_Closed},
+	model.SessionState_Open:    {model.SessionState_PreOpen, model.SessionState_Halted, model.SessionState_Closed},
+	model.SessionState_Halted:  {model.SessionState_PreOpen, model.SessionState_Open, model.SessionState_Closed},
+	model.SessionState_Closed:  {model.SessionState_PreOpen, model.SessionState_Open},
+}
+
+func (me *MatchingEngine) GetSessionState() model.SessionState {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	return me.session
+}
+
+// SetSessionState moves the engine to another session state:
+//
+//   - PRE_OPEN collects orders for an opening auction, see StartAuction.
+//   - OPEN matches continuously. Entering it uncrosses a running auction.
+//   - HALTED only accepts cancels. Resting orders stay on the book.
+//   - CLOSED rejects everything. Good-till-date orders still expire.
+//
+// New orders the session does not accept are rejected with
+// RejectReason_SessionHalted or RejectReason_SessionClosed, and other commands
+// fail with ErrSymbolHalted or ErrSymbolClosed. Moving to the current state
+// does nothing, and a closed session can not be halted.
+func (me *MatchingEngine) SetSessionState(state model.SessionState) (model.MatchResult, error) {
+	cr := me.execute(Command{Session: &model.SessionRequest{Symbol: me.symbol, State: state}}, time.Now())
+	return cr.Result, cr.Err
+}
+
+func (me *MatchingEngine) setSession(to model.SessionState, reason model.SessionChangeReason) (r model.MatchResult, err error) {
+	from := me.session
+	if to == from {
+		return
+	}
+	allowed := false
+	for _, s := range sessionTransitions[from] {
+		allowed = allowed || s == to
+	}
+	if !allowed {
+		err = ErrInvalidTransition
+		return
+	}
+	me.session = to
+	if me.breaker != nil {
+		me.breaker.haltUntil = time.Time{}
+	}
+	change := model.SessionStateChange{From: from, To: to, Reason: reason, EventTime: model.Timestamp{Time: me.now}}
+	r.SessionState = &change
+	me.publish(model.Event{Type: model.EventType_SessionState, SessionState: &change})
+	switch {
+	case to == model.SessionState_PreOpen:
+		me.auction = true
+	case to == model.SessionState_Open && me.auction:
+		r.Append(me.uncross())
+		me.releaseStops(&r, r.Trades)
+	}
+	return
+}
+
+// checkSession tells if the current session refuses cmd. New orders come
+// with a reason to reject them with.
+func (me *MatchingEngine) checkSession(cmd Command) (model.RejectReason, error) {
+	isOrder := cmd.Limit != nil || cmd.Market != nil || cmd.Stop != nil
+	switch me.session {
+	case model.SessionState_Halted:
+		if isOrder {
+			return model.RejectReason_SessionHalted, ErrSymbolHalted
+		}
+		if cmd.Amend != nil || cmd.StartAuction || cmd.Uncross {
+			return "", ErrSymbolHalted
+		}
+	case model.SessionState_Closed:
+		if isOrder {
+			return model.RejectReason_SessionClosed, ErrSymbolClosed
+		}
+		if cmd.Session == nil && !cmd.Expire {
+			return "", ErrSymbolClosed
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,77 @@
+package matchingenginecore_test
+
+import (
+	"errors"
+	"testing"
+
+	me "github.com/dylantkx/matching-engine-core"
+	"github.com/dylantkx/matching-engine-core/model"
+	"github.com/shopspring/decimal"
+)
+
+func TestSessionStates(t *testing.T) {
+	sink := me.NewRingBufferSink(100, model.OverflowPolicy_Drop)
+	engine := me.NewMatchingEngine(me.WithSessionState(model.SessionState_Closed), me.WithEventSink(sink))
+
+	r := addLimit(engine, "1", model.OrderSide_Sell, 1, 100)
+	if len(r.Rejections) != 1 || r.Rejections[0].Reason != model.RejectReason_SessionClosed {
+		t.Fatalf("wrong rejection output: %+v", r.Rejections)
+	}
+
+	r, err := engine.SetSessionState(model.SessionState_PreOpen)
+	if err != nil || r.SessionState == nil || r.SessionState.From != model.SessionState_Closed || r.SessionState.To != model.SessionState_PreOpen {
+		t.Fatalf("wrong state change: %+v, %v", r.SessionState, err)
+	}
+	addLimit(engine, "2", model.OrderSide_Sell, 1, 100)
+	r = addLimit(engine, "3", model.OrderSide_Buy, 2, 101)
+	if len(r.Trades) != 0 {
+		t.Fatalf("expect no trades before the open but got %+v", r.Trades)
+	}
+
+	r, err = engine.SetSessionState(model.SessionState_Open)
+	if err != nil || len(r.Trades) != 1 || !r.Trades[0].Price.Equal(decimal.NewFromFloat(100)) {
+		t.Fatalf("expect the open to uncross but got %+v, %v", r, err)
+	}
+	if engine.IsInAuction() {
+		t.Fatalf("expect continuous matching after the open")
+	}
+
+	engine.SetSessionState(model.SessionState_Halted)
+	r = addLimit(engine, "4", model.OrderSide_Sell, 1, 101)
+	if len(r.Rejections) != 1 || r.Rejections[0].Reason != model.RejectReason_SessionHalted {
+		t.Fatalf("wrong rejection output: %+v", r.Rejections)
+	}
+	if _, _, err := engine.AmendOrder(&model.OrderAmend{OrderID: "3", Units: decimal.NewFromFloat(0.5)}); !errors.Is(err, me.ErrSymbolHalted) {
+		t.Fatalf("expect symbol halted error but got %v", err)
+	}
+	if r, err := engine.CancelOrderByID("3"); err != nil || len(r.Cancellations) != 1 {
+		t.Fatalf("expect cancel to go through while halted, but got %+v, %v", r, err)
+	}
+
+	engine.SetSessionState(model.SessionState_Closed)
+	if _, err := engine.CancelOrderByID("3"); !errors.Is(err, me.ErrSymbolClosed) {
+		t.Fatalf("expect symbol closed error but got %v", err)
+	}
+	if _, err := engine.SetSessionState(model.SessionState_Halted); !errors.Is(err, me.ErrInvalidTransition) {
+		t.Fatalf("expect invalid transition error but got %v", err)
+	}
+	if s := engine.GetSessionState(); s != model.SessionState_Closed {
+		t.Fatalf("expect session to stay closed but got %s", s)
+	}
+
+	var changes []model.SessionState
+	for e, ok := sink.TryNext(); ok; e, ok = sink.TryNext() {
+		if e.Type == model.EventType_SessionState {
+			changes = append(changes, e.SessionState.To)
+		}
+	}
+	expected := []model.SessionState{model.SessionState_PreOpen, model.SessionState_Open, model.SessionState_Halted, model.SessionState_Closed}
+	if len(changes) != len(expected) {
+		t.Fatalf("expect %v state change events but got %v", expected, changes)
+	}
+	for i := range expected {
+		if changes[i] != expected[i] {
+			t.Fatalf("expect %v state change events but got %v", expected, changes)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package matchingenginecore
+
+import (
+	"sort"
+	"time"
+
+	"github.com/dylantkx/matching-engine-core/model"
+	"github.com/dylantkx/matching-engine-core/orderbook"
+	"github.com/shopspring/decimal"
+)
+
+// EngineSnapshot is the full state of an engine. Limits holds the resting
+// limit orders as they were submitted, good-till-date orders first in the
+// order they expire, and Stops the pending stop orders in submission order.
+// Seq is the last journaled sequence number, and Auction tells if the engine
+// is collecting orders for an uncross.
+type EngineSnapshot struct {
+	Seq       uint64                `json:"seq"`
+	LastPrice decimal.Decimal       `json:"lastPrice"`
+	Book      *orderbook.L3Snapshot `json:"book"`
+	Limits    []model.OrderLimit    `json:"limits"`
+	Stops     []model.OrderStop     `json:"stops"`
+	States    []*OrderState         `json:"states"`
+	Session   model.SessionState    `json:"session"`
+	Auction   bool                  `json:"auction,omitempty"`
+	// HaltUntil is the end of the cooling-off period of a tripped circuit
+	// breaker.
+	HaltUntil *model.Timestamp `json:"haltUntil,omitempty"`
+}
+
+func (me *MatchingEngine) Snapshot() *EngineSnapshot {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	sn := &EngineSnapshot{
+		Seq:       me.seq,
+		LastPrice: me.lastPrice,
+		Book:      me.book.GetL3Snapshot(),
+		Limits:    make([]model.OrderLimit, 0, len(me.orders)),
+		Stops:     make([]model.OrderStop, 0, me.stops.Len()),
+		States:    make([]*OrderState, 0, len(me.states)),
+		Session:   me.session,
+		Auction:   me.auction,
+	}
+	if me.breaker != nil && !me.breaker.haltUntil.IsZero() {
+		sn.HaltUntil = &model.Timestamp{Time: me.breaker.haltUntil}
+	}
+
+	added := make(map[string]bool)
+	for _, id := range me.expiries.IDs() {
+		if order, ok := me.orders[id]; ok {
+			sn.Limits = append(sn.Limits, order)
+			added[id] = true
+		}
+	}
+	rest := len(sn.Limits)
+	for id, order := range me.orders {
+		if !added[id] {
+			sn.Limits = append(sn.Limits, order)
+		}
+	}
+	sort.Slice(sn.Limits[rest:], func(i, j int) bool {
+		return sn.Limits[rest+i].ID < sn.Limits[rest+j].ID
+	})
+
+	for _, stop := range me.stops.Orders() {
+		sn.Stops = append(sn.Stops, *stop)
+	}
+	for _, s := range me.states {
+		state := *s
+		sn.States = append(sn.States, &state)
+	}
+	sort.Slice(sn.States, func(i, j int) bool {
+		return sn.States[i].OrderID < sn.States[j].OrderID
+	})
+	return sn
+}
+
+// Restore replaces the state of the engine with sn. The engine must not be
+// in use while it is restored.
+func (me *MatchingEngine) Restore(sn *EngineSnapshot) error {
+	b, err := orderbook.NewBookFromSnapshot(sn.Book, me.bookOptions()...)
+	if err != nil {
+		return err
+	}
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	me.book = b
+	me.book.SetDepthListener(me.addDepthUpdate)
+	me.seq = sn.Seq
+	me.lastPrice = sn.LastPrice
+	me.session = sn.Session
+	if me.session == "" {
+		me.session = model.SessionState_Open
+	}
+	me.auction = sn.Auction
+	if me.breaker != nil {
+		me.breaker.haltUntil = time.Time{}
+		if sn.HaltUntil != nil {
+			me.breaker.haltUntil = sn.HaltUntil.Time
+		}
+	}
+	me.indicative = model.AuctionIndicative{}
+	if me.auction {
+		me.indicative = me.indicativeUncross()
+	}
+	me.orders = make(map[string]model.OrderLimit)
+	me.expiries = newExpiryBook()
+	for _, order := range sn.Limits {
+		me.orders[order.ID] = order
+		if order.TimeInForce == model.TimeInForce_GTD {
+			me.expiries.Add(order.ID, order.ExpireTime.Time)
+		}
+	}
+	me.stops = newStopBook()
+	for i := range sn.Stops {
+		stop := sn.Stops[i]
+		me.stops.Add(&stop)
+	}
+	me.states = make(map[string]*OrderState)
+	for _, s := range sn.States {
+		state := *s
+		me.states[state.OrderID] = &state
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package matchingenginecore_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	me "github.com/dylantkx/matching-engine-core"
+	"github.com/dylantkx/matching-engine-core/model"
+	"github.com/shopspring/decimal"
+)
+
+func TestSnapshotRestoreRoundTrip(t *testing.T) {
+	engine := me.NewMatchingEngine()
+	for i := 1; i <= 9; i++ {
+		engine.ProcessLimitOrder(&model.OrderLimit{
+			ID:    fmt.Sprintf("s%d", i),
+			Units: decimal.NewFromFloat(float64(i)),
+			Price: decimal.NewFromFloat(float64(100 + i%3)),
+			Side:  model.OrderSide_Sell,
+		})
+		engine.ProcessLimitOrder(&model.OrderLimit{
+			ID:          fmt.Sprintf("b%d", i),
+			Units:       decimal.NewFromFloat(float64(i)),
+			Price:       decimal.NewFromFloat(float64(90 + i%3)),
+			Side:        model.OrderSide_Buy,
+			TimeInForce: model.TimeInForce_GTD,
+			ExpireTime:  model.Timestamp{Time: time.Now().Add(time.Duration(i) * time.Hour)},
+		})
+	}
+	engine.ProcessLimitOrder(&model.OrderLimit{
+		ID:           "iceberg",
+		Units:        decimal.NewFromFloat(10),
+		Price:        decimal.NewFromFloat(100),
+		Side:         model.OrderSide_Sell,
+		DisplayUnits: decimal.NewFromFloat(2),
+	})
+	engine.ProcessMarketOrder(&model.OrderMarket{
+		ID:    "m1",
+		Units: decimal.NewFromFloat(4),
+		Side:  model.OrderSide_Buy,
+	})
+	engine.ProcessStopOrder(&model.OrderStop{
+		ID:        "stop",
+		Type:      model.OrderType_StopMarket,
+		Units:     decimal.NewFromFloat(3),
+		StopPrice: decimal.NewFromFloat(101),
+		Side:      model.OrderSide_Buy,
+	})
+
+	b, err := json.Marshal(engine.Snapshot())
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	var sn me.EngineSnapshot
+	if err := json.Unmarshal(b, &sn); err != nil {
+		t.Fatalf(err.Error())
+	}
+	for _, s := range sn.States {
+		if s.OrderID == "s6" && !s.CumUnits.Equal(decimal.NewFromFloat(1)) {
+			t.Fatalf("expect s6 to be partly filled in its state, but got %+v", s)
+		}
+	}
+	restored := me.NewMatchingEngine()
+	if err := restored.Restore(&sn); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	want, _ := json.Marshal(engine.GetOrderBookFullSnapshot())
+	got, _ := json.Marshal(restored.GetOrderBookFullSnapshot())
+	if string(want) != string(got) {
+		t.Fatalf("expect restored book %s, but got %s", want, got)
+	}
+
+	follow := func(e *me.MatchingEngine) string {
+		var rs []model.MatchResult
+		rs = append(rs, e.ProcessMarketOrder(&model.OrderMarket{
+			ID:    "m2",
+			Units: decimal.NewFromFloat(30),
+			Side:  model.OrderSide_Buy,
+		}))
+		rs = append(rs, e.ExpireOrders(time.Now().Add(5*time.Hour)))
+		rs = append(rs, e.ProcessMarketOrder(&model.OrderMarket{
+			ID:    "m3",
+			Units: decimal.NewFromFloat(50),
+			Side:  model.OrderSide_Sell,
+		}))
+		for i := range rs {
+			for j := range rs[i].Trades {
+				rs[i].Trades[j].EventTime = model.Timestamp{}
+			}
+			for j := range rs[i].Reports {
+				rs[i].Reports[j].EventTime = model.Timestamp{}
+			}
+		}
+		b, _ := json.Marshal(rs)
+		return string(b)
+	}
+	if w, g := follow(engine), follow(restored); w != g {
+		t.Fatalf("expect identical results after restore, want %s but got %s", w, g)
+	}
+}
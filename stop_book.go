@@ -0,0 +1,125 @@
+package matchingenginecore
+
+import (
+	"sort"
+
+	"github.com/dylantkx/matching-engine-core/model"
+	"github.com/google/btree"
+	"github.com/shopspring/decimal"
+)
+
+const treeDegree int = 2
+
+type stopBookItem struct {
+	Order *model.OrderStop
+	Seq   uint64
+}
+
+// stopBook keeps stop orders that are waiting for their trigger price. Buy
+// stops are ordered by ascending stop price and sell stops by descending stop
+// price, so the ones closest to triggering are always at the front.
+type stopBook struct {
+	buyTree  *btree.BTreeG[stopBookItem]
+	sellTree *btree.BTreeG[stopBookItem]
+	orders   map[string]stopBookItem
+	seq      uint64
+}
+
+func newStopBook() *stopBook {
+	return &stopBook{
+		buyTree: btree.NewG(treeDegree, func(a, b stopBookItem) bool {
+			if !a.Order.StopPrice.Equal(b.Order.StopPrice) {
+				return a.Order.StopPrice.LessThan(b.Order.StopPrice)
+			}
+			return a.Seq < b.Seq
+		}),
+		sellTree: btree.NewG(treeDegree, func(a, b stopBookItem) bool {
+			if !a.Order.StopPrice.Equal(b.Order.StopPrice) {
+				return a.Order.StopPrice.GreaterThan(b.Order.StopPrice)
+			}
+			return a.Seq < b.Seq
+		}),
+		orders: make(map[string]stopBookItem),
+	}
+}
+
+func (sb *stopBook) Add(order *model.OrderStop) {
+	sb.seq++
+	item := stopBookItem{
+		Order: order,
+		Seq:   sb.seq,
+	}
+	sb.treeOf(order.Side).ReplaceOrInsert(item)
+	sb.orders[order.ID] = item
+}
+
+func (sb *stopBook) Get(id string) (*model.OrderStop, bool) {
+	item, ok := sb.orders[id]
+	return item.Order, ok
+}
+
+func (sb *stopBook) Remove(id string) (*model.OrderStop, bool) {
+	item, ok := sb.orders[id]
+	if !ok {
+		return nil, false
+	}
+	sb.treeOf(item.Order.Side).Delete(item)
+	delete(sb.orders, id)
+	return item.Order, true
+}
+
+// Orders returns every pending stop order in the order they were submitted.
+func (sb *stopBook) Orders() []*model.OrderStop {
+	items := make([]stopBookItem, 0, len(sb.orders))
+	for _, item := range sb.orders {
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Seq < items[j].Seq
+	})
+	orders := make([]*model.OrderStop, 0, len(items))
+	for _, item := range items {
+		orders = append(orders, item.Order)
+	}
+	return orders
+}
+
+func (sb *stopBook) Len() int {
+	return len(sb.orders)
+}
+
+// PopTriggered removes and returns every stop order triggered by a trade
+// printing anywhere between low and high, in the order they were submitted.
+func (sb *stopBook) PopTriggered(low, high decimal.Decimal) []*model.OrderStop {
+	var items []stopBookItem
+	sb.buyTree.Ascend(func(item stopBookItem) bool {
+		if !item.Order.IsTriggeredBy(high) {
+			return false
+		}
+		items = append(items, item)
+		return true
+	})
+	sb.sellTree.Ascend(func(item stopBookItem) bool {
+		if !item.Order.IsTriggeredBy(low) {
+			return false
+		}
+		items = append(items, item)
+		return true
+	})
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Seq < items[j].Seq
+	})
+	orders := make([]*model.OrderStop, 0, len(items))
+	for _, item := range items {
+		sb.Remove(item.Order.ID)
+		orders = append(orders, item.Order)
+	}
+	return orders
+}
+
+func (sb *stopBook) treeOf(side model.OrderSide) *btree.BTreeG[stopBookItem] {
+	if side == model.OrderSide_Buy {
+		return sb.buyTree
+	}
+	return sb.sellTree
+}
@@ -0,0 +1,148 @@
+package matchingenginecore_test
+
+import (
+	"testing"
+
+	me "github.com/dylantkx/matching-engine-core"
+	"github.com/dylantkx/matching-engine-core/model"
+	"github.com/shopspring/decimal"
+)
+
+func TestStopMarketOrderTriggeredByTrade(t *testing.T) {
+	engine := me.NewMatchingEngine()
+
+	sellOrders := []model.OrderLimit{
+		{
+			ID:    "1",
+			Units: decimal.NewFromFloat(1),
+			Price: decimal.NewFromFloat(100),
+			Side:  model.OrderSide_Sell,
+		},
+		{
+			ID:    "2",
+			Units: decimal.NewFromFloat(1),
+			Price: decimal.NewFromFloat(110),
+			Side:  model.OrderSide_Sell,
+		},
+	}
+	for _, ord := range sellOrders {
+		engine.ProcessLimitOrder(&ord)
+	}
+
+	r := engine.ProcessStopOrder(&model.OrderStop{
+		ID:        "3",
+		Type:      model.OrderType_StopMarket,
+		Units:     decimal.NewFromFloat(1),
+		StopPrice: decimal.NewFromFloat(100),
+		Side:      model.OrderSide_Buy,
+	})
+	if len(r.Trades) != 0 {
+		t.Fatalf("expect no trades but got %d", len(r.Trades))
+	}
+
+	r = engine.ProcessMarketOrder(&model.OrderMarket{
+		ID:    "4",
+		Units: decimal.NewFromFloat(1),
+		Side:  model.OrderSide_Buy,
+	})
+	if len(r.Trades) != 2 {
+		t.Fatalf("expect 2 trades but got %d", len(r.Trades))
+	}
+	tr := r.Trades[1]
+	if tr.BuyOrderID != "3" || tr.SellOrderID != "2" || !tr.Price.Equal(decimal.NewFromFloat(110)) {
+		t.Fatalf("wrong trade output: %+v", tr)
+	}
+	if p := engine.GetLastTradePrice(); !p.Equal(decimal.NewFromFloat(110)) {
+		t.Fatalf("expect last trade price to be 110, but got %s", p)
+	}
+}
+
+func TestStopOrdersCascade(t *testing.T) {
+	engine := me.NewMatchingEngine()
+
+	buyOrders := []model.OrderLimit{
+		{
+			ID:    "1",
+			Units: decimal.NewFromFloat(1),
+			Price: decimal.NewFromFloat(100),
+			Side:  model.OrderSide_Buy,
+		},
+		{
+			ID:    "2",
+			Units: decimal.NewFromFloat(1),
+			Price: decimal.NewFromFloat(90),
+			Side:  model.OrderSide_Buy,
+		},
+		{
+			ID:    "3",
+			Units: decimal.NewFromFloat(1),
+			Price: decimal.NewFromFloat(80),
+			Side:  model.OrderSide_Buy,
+		},
+	}
+	for _, ord := range buyOrders {
+		engine.ProcessLimitOrder(&ord)
+	}
+
+	stops := []model.OrderStop{
+		{
+			ID:        "4",
+			Type:      model.OrderType_StopLimit,
+			Units:     decimal.NewFromFloat(1),
+			Price:     decimal.NewFromFloat(80),
+			StopPrice: decimal.NewFromFloat(90),
+			Side:      model.OrderSide_Sell,
+		},
+		{
+			ID:        "5",
+			Type:      model.OrderType_StopMarket,
+			Units:     decimal.NewFromFloat(1),
+			StopPrice: decimal.NewFromFloat(100),
+			Side:      model.OrderSide_Sell,
+		},
+	}
+	for _, ord := range stops {
+		engine.ProcessStopOrder(&ord)
+	}
+
+	r := engine.ProcessMarketOrder(&model.OrderMarket{
+		ID:    "6",
+		Units: decimal.NewFromFloat(1),
+		Side:  model.OrderSide_Sell,
+	})
+	if len(r.Trades) != 3 {
+		t.Fatalf("expect 3 trades but got %d", len(r.Trades))
+	}
+	for i, id := range []string{"6", "5", "4"} {
+		if r.Trades[i].SellOrderID != id {
+			t.Fatalf("expect trade %d to be sold by %s, but got %+v", i, id, r.Trades[i])
+		}
+	}
+	if p := engine.GetHighestBuyPrice(); !p.IsZero() {
+		t.Fatalf("expect highest buy to be 0, but got %s", p)
+	}
+}
+
+func TestCancelStopOrder(t *testing.T) {
+	engine := me.NewMatchingEngine()
+
+	engine.ProcessStopOrder(&model.OrderStop{
+		ID:        "1",
+		Type:      model.OrderType_StopMarket,
+		Units:     decimal.NewFromFloat(1),
+		StopPrice: decimal.NewFromFloat(100),
+		Side:      model.OrderSide_Buy,
+	})
+
+	r, err := engine.CancelOrderByID("1")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	cancels := r.Cancellations
+	if len(cancels) != 1 || cancels[0].OrderID != "1" || !cancels[0].Units.Equal(decimal.NewFromFloat(1)) {
+		t.Fatalf("wrong cancel output: %+v", cancels)
+	}
+	if _, err := engine.CancelOrderByID("1"); err == nil {
+		t.Fatalf("expect stop order to be gone")
+	}
+}
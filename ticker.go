@@ -0,0 +1,99 @@
+package matchingenginecore
+
+import (
+	"time"
+
+	"github.com/dylantkx/matching-engine-core/model"
+	"github.com/shopspring/decimal"
+)
+
+const defaultTickerWindow time.Duration = 24 * time.Hour
+
+type tickerTrade struct {
+	Seq   uint64
+	Time  time.Time
+	Price decimal.Decimal
+	Units decimal.Decimal
+}
+
+// rollingTicker keeps the statistics of the trades inside a sliding window.
+// Sums are adjusted as trades enter and leave the window, and the highest and
+// lowest prices are kept in monotonic queues, so no update looks at the whole
+// window.
+type rollingTicker struct {
+	window      time.Duration
+	trades      []tickerTrade
+	highs       []tickerTrade
+	lows        []tickerTrade
+	volume      decimal.Decimal
+	quoteVolume decimal.Decimal
+	last        tickerTrade
+	seq         uint64
+}
+
+func newRollingTicker(window time.Duration) *rollingTicker {
+	return &rollingTicker{window: window}
+}
+
+func (rt *rollingTicker) addTrade(tr model.Trade) {
+	rt.seq++
+	t := tickerTrade{
+		Seq:   rt.seq,
+		Time:  tr.EventTime.Time,
+		Price: tr.Price,
+		Units: tr.Units,
+	}
+	rt.evict(t.Time)
+	rt.last = t
+	rt.trades = append(rt.trades, t)
+	rt.volume = rt.volume.Add(t.Units)
+	rt.quoteVolume = rt.quoteVolume.Add(t.Units.Mul(t.Price))
+	for len(rt.highs) > 0 && rt.highs[len(rt.highs)-1].Price.LessThanOrEqual(t.Price) {
+		rt.highs = rt.highs[:len(rt.highs)-1]
+	}
+	rt.highs = append(rt.highs, t)
+	for len(rt.lows) > 0 && rt.lows[len(rt.lows)-1].Price.GreaterThanOrEqual(t.Price) {
+		rt.lows = rt.lows[:len(rt.lows)-1]
+	}
+	rt.lows = append(rt.lows, t)
+}
+
+// evict drops the trades that are no longer inside the window ending at now.
+func (rt *rollingTicker) evict(now time.Time) {
+	cutoff := now.Add(-rt.window)
+	for len(rt.trades) > 0 && !rt.trades[0].Time.After(cutoff) {
+		t := rt.trades[0]
+		rt.trades = rt.trades[1:]
+		rt.volume = rt.volume.Sub(t.Units)
+		rt.quoteVolume = rt.quoteVolume.Sub(t.Units.Mul(t.Price))
+		if rt.highs[0].Seq == t.Seq {
+			rt.highs = rt.highs[1:]
+		}
+		if rt.lows[0].Seq == t.Seq {
+			rt.lows = rt.lows[1:]
+		}
+	}
+}
+
+func (rt *rollingTicker) ticker(now time.Time) model.Ticker {
+	rt.evict(now)
+	tk := model.Ticker{
+		LastPrice: rt.last.Price,
+		LastUnits: rt.last.Units,
+		OpenTime:  model.Timestamp{Time: now.Add(-rt.window)},
+		CloseTime: model.Timestamp{Time: now},
+	}
+	if len(rt.trades) == 0 {
+		return tk
+	}
+	tk.Open = rt.trades[0].Price
+	tk.High = rt.highs[0].Price
+	tk.Low = rt.lows[0].Price
+	tk.Volume = rt.volume
+	tk.QuoteVolume = rt.quoteVolume
+	tk.PriceChange = tk.LastPrice.Sub(tk.Open)
+	tk.PriceChangePercent = tk.PriceChange.Div(tk.Open).Mul(decimal.NewFromInt(100))
+	tk.VWAP = tk.QuoteVolume.Div(tk.Volume)
+	tk.TradeCount = len(rt.trades)
+	return tk
+}
@@ -0,0 +1,91 @@
+package matchingenginecore_test
+
+import (
+	"testing"
+	"time"
+
+	me "github.com/dylantkx/matching-engine-core"
+	"github.com/dylantkx/matching-engine-core/model"
+	"github.com/shopspring/decimal"
+)
+
+func TestTickerRollingWindow(t *testing.T) {
+	now := time.Now()
+	var entries []me.JournalEntry
+	add := func(at time.Duration, cmd me.Command) {
+		entries = append(entries, me.JournalEntry{
+			Seq:     uint64(len(entries) + 1),
+			Time:    now.Add(-at),
+			Command: cmd,
+		})
+	}
+	trade := func(at time.Duration, id string, price, units float64) {
+		add(at, me.Command{Limit: &model.OrderLimit{
+			ID:    id + "s",
+			Units: decimal.NewFromFloat(units),
+			Price: decimal.NewFromFloat(price),
+			Side:  model.OrderSide_Sell,
+		}})
+		add(at, me.Command{Market: &model.OrderMarket{
+			ID:    id + "b",
+			Units: decimal.NewFromFloat(units),
+			Side:  model.OrderSide_Buy,
+		}})
+	}
+	trade(25*time.Hour, "1", 500, 10)
+	trade(23*time.Hour, "2", 100, 1)
+	trade(10*time.Hour, "3", 120, 2)
+	trade(5*time.Hour, "4", 90, 1)
+	trade(time.Hour, "5", 110, 1)
+
+	engine, err := me.Replay(entries)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	tk := engine.GetTicker()
+	checks := []struct {
+		name string
+		got  decimal.Decimal
+		want float64
+	}{
+		{"last price", tk.LastPrice, 110},
+		{"last units", tk.LastUnits, 1},
+		{"open", tk.Open, 100},
+		{"high", tk.High, 120},
+		{"low", tk.Low, 90},
+		{"volume", tk.Volume, 5},
+		{"quote volume", tk.QuoteVolume, 540},
+		{"price change", tk.PriceChange, 10},
+		{"price change percent", tk.PriceChangePercent, 10},
+		{"vwap", tk.VWAP, 108},
+	}
+	for _, c := range checks {
+		if !c.got.Equal(decimal.NewFromFloat(c.want)) {
+			t.Fatalf("expect %s to be %v, but got %s", c.name, c.want, c.got)
+		}
+	}
+	if tk.TradeCount != 4 {
+		t.Fatalf("expect 4 trades but got %d", tk.TradeCount)
+	}
+}
+
+func TestTickerWithoutRecentTrades(t *testing.T) {
+	engine := me.NewMatchingEngine(me.WithTickerWindow(time.Nanosecond))
+	engine.ProcessLimitOrder(&model.OrderLimit{
+		ID:    "1",
+		Units: decimal.NewFromFloat(1),
+		Price: decimal.NewFromFloat(100),
+		Side:  model.OrderSide_Sell,
+	})
+	engine.ProcessMarketOrder(&model.OrderMarket{
+		ID:    "2",
+		Units: decimal.NewFromFloat(1),
+		Side:  model.OrderSide_Buy,
+	})
+	time.Sleep(time.Millisecond)
+
+	tk := engine.GetTicker()
+	if !tk.LastPrice.Equal(decimal.NewFromFloat(100)) || tk.TradeCount != 0 || !tk.Volume.IsZero() {
+		t.Fatalf("expect only the last trade to be reported, but got %+v", tk)
+	}
+}